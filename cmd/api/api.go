@@ -1,21 +1,30 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
+	"flightticketservice/pkg/auth"
 	t "flightticketservice/pkg/booking"
 	f "flightticketservice/pkg/flights"
 	p "flightticketservice/pkg/passenger"
-	"flightticketservice/utils"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
 	"time"
 
-	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// holdReapInterval is how often expired seat holds are released.
+const holdReapInterval = 30 * time.Second
+
+// tokenReapInterval is how often expired refresh tokens and revoked access tokens are deleted.
+const tokenReapInterval = time.Hour
+
+// keyCheckInterval is how often signing keys are reloaded, rotated when due and retired.
+const keyCheckInterval = time.Minute
+
 // APIServer collects service settings and storage
 type APIServer struct {
 	listenAddr string
@@ -23,6 +32,20 @@ type APIServer struct {
 	store      p.Storage
 	flights    f.FlightService
 	tickets    t.BookingService
+	uow        unitOfWork
+	tokens     *auth.Service
+	holdTTL    time.Duration
+	timeouts   Timeouts
+	logger     *slog.Logger
+	registry   *prometheus.Registry
+
+	middlewares     []mux.MiddlewareFunc
+	requestDuration *prometheus.HistogramVec
+
+	srv     *http.Server
+	workers []*worker
+	closers []closer
+	errs    chan error
 }
 
 // NewAPIServer creates API server
@@ -32,143 +55,169 @@ func NewAPIServer(
 	store p.Storage,
 	flightsStore f.FlightService,
 	ticketStore t.BookingService,
+	uow unitOfWork,
+	tokens *auth.Service,
+	holdTTL time.Duration,
+	timeouts Timeouts,
+	logger *slog.Logger,
+	registry *prometheus.Registry,
 ) *APIServer {
-	return &APIServer{
+	s := &APIServer{
 		listenAddr: listenAddr,
 		listenPort: listenPort,
 		store:      store,
 		flights:    flightsStore,
 		tickets:    ticketStore,
+		uow:        uow,
+		tokens:     tokens,
+		holdTTL:    holdTTL,
+		timeouts:   timeouts,
+		logger:     logger,
+		registry:   registry,
+
+		requestDuration: newRequestDuration(registry),
 	}
+	s.Use(s.withRequestID, s.withTracing, s.withLogContext, s.withAccessLog, s.withMetrics, s.withRecovery, s.withAuthorization, s.withTimeout)
+	s.AddWorker("hold reaper", t.NewHoldReaper(ticketStore, holdReapInterval, logger).Run)
+	s.AddWorker("token reaper", auth.NewReaper(tokens, tokenReapInterval, logger).Run)
+	registry.MustRegister(t.NewSeatsCollector(ticketStore, logger))
+
+	return s
 }
 
-// Run launches http server
-func (s *APIServer) Run() {
+// Router registers API routes
+func (s *APIServer) Router() *mux.Router {
 	r := mux.NewRouter()
-
-	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
-
-	r.HandleFunc("/api/v1/login", s.handleLogin).Methods("POST")
-
-	r.HandleFunc("/api/v1/flights", s.handleGetFlights).Methods("GET")
-	r.HandleFunc("/api/v1/flights/search", s.handleGetFlightByParams).Methods("GET")
-	r.HandleFunc("/api/v1/flights/{id}", s.handleGetFlightByID).Methods("GET")
-	r.HandleFunc("/api/v1/flights/create", s.handleCreateFlight).Methods("POST")
-	r.HandleFunc("/api/v1/flights/{id}/update", s.handleUpdateFlight).Methods("POST")
-	r.HandleFunc("/api/v1/flights/{id}/delete", s.handleDeleteFlight).Methods("DELETE")
-
-	r.HandleFunc("/api/v1/passengers", s.handleGetPassengers).Methods("GET")
-	r.HandleFunc("/api/v1/passengers/{id}", withJWTAuth(s.handleGetPassengerByID, s.store)).Methods("GET")
-	r.HandleFunc("/api/v1/passengers/create", s.handleCreatePassenger).Methods("POST")
-	r.HandleFunc("/api/v1/passengers/{id}/update", s.handleUpdatePassenger).Methods("POST")
-	r.HandleFunc("/api/v1/passengers/{id}/delete ", s.handleDeletePassenger).Methods("DELETE")
-
-	r.HandleFunc("/api/v1/tickets", s.handleGetTickets).Methods("GET")
-	r.HandleFunc("/api/v1/tickets/{id}", s.handleGetTicketByID).Methods("GET")
-	r.HandleFunc("/api/v1/tickets/book", s.handleBookTicket).Methods("POST")
-	r.HandleFunc("/api/v1/checkin", s.handleCheckInOnline).Methods("POST")
-	r.HandleFunc("/api/v1/tickets/{id}/change", s.handleChangeTicket).Methods("POST")
-	r.HandleFunc("/api/v1/tickets/{id}/cancel", s.handleCancelTicket).Methods("POST")
-
-	r.HandleFunc("/api/v1/tickets/create", s.handleCreateTicket).Methods("POST")
-	r.HandleFunc("/api/v1/tickets/{id}/update", s.handleUpdateTicket).Methods("POST")
-	r.HandleFunc("/api/v1/tickets/{id}/delete", s.handleDeleteTicket).Methods("DELETE")
-
-	utils.InfoLog.Println("JSON API server running on port: ", s.listenAddr)
-
-	srv := &http.Server{
-		Handler:      r,
-		Addr:         s.listenAddr + ":" + s.listenPort,
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
-	}
-
-	utils.InfoLog.Println("Starting server on", srv.Addr)
-	if err := srv.ListenAndServe(); err != nil {
-		utils.ErrorLog.Fatal("Server failed to start:", err)
-	}
+	r.Use(s.middlewares...)
+	r.NotFoundHandler = s.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.writeError(w, r, errRouteNotFound)
+	}))
+	r.MethodNotAllowedHandler = s.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, newProblem(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method "+r.Method+" is not allowed"))
+	}))
+
+	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler).Name("swagger")
+	r.Handle("/metrics", s.metricsHandler()).Methods("GET").Name("metrics")
+
+	r.HandleFunc("/.well-known/jwks.json", s.handleJWKS).Methods("GET").Name("auth.jwks")
+	r.HandleFunc("/api/v1/login", s.handleLogin).Methods("POST").Name("auth.login")
+	r.HandleFunc("/api/v1/token/refresh", s.handleRefreshToken).Methods("POST").Name("auth.refresh")
+	r.HandleFunc("/api/v1/logout", s.handleLogout).Methods("POST").Name("auth.logout")
+
+	r.HandleFunc("/api/v1/flights", s.handleGetFlights).Methods("GET").Name("flights.list")
+	r.HandleFunc("/api/v1/flights/search", s.handleGetFlightByParams).Methods("GET").Name("flights.search")
+	r.HandleFunc("/api/v1/flights/itineraries", s.handleSearchItineraries).Methods("POST").Name("flights.itineraries")
+	r.HandleFunc("/api/v1/flights/{id}", s.handleGetFlightByID).Methods("GET").Name("flights.get")
+	r.HandleFunc("/api/v1/flights/{id}/seats", s.handleGetFlightSeats).Methods("GET").Name("flights.seats")
+	r.HandleFunc("/api/v1/flights/create", s.handleCreateFlight).Methods("POST").Name("flights.create")
+	r.HandleFunc("/api/v1/flights/{id}/update", s.handleUpdateFlight).Methods("POST").Name("flights.update")
+	r.HandleFunc("/api/v1/flights/{id}/delete", s.handleDeleteFlight).Methods("DELETE").Name("flights.delete")
+
+	r.HandleFunc("/api/v1/passengers", s.handleGetPassengers).Methods("GET").Name("passengers.list")
+	r.HandleFunc("/api/v1/passengers/{id}", s.handleGetPassengerByID).Methods("GET").Name("passengers.get")
+	r.HandleFunc("/api/v1/passengers/create", s.handleCreatePassenger).Methods("POST").Name("passengers.create")
+	r.HandleFunc("/api/v1/passengers/{id}/update", s.handleUpdatePassenger).Methods("POST").Name("passengers.update")
+	r.HandleFunc("/api/v1/passengers/{id}/role", s.handleSetPassengerRole).Methods("POST").Name("passengers.role")
+	r.HandleFunc("/api/v1/passengers/{id}/delete", s.handleDeletePassenger).Methods("DELETE").Name("passengers.delete")
+
+	r.HandleFunc("/api/v1/tickets", s.handleGetTickets).Methods("GET").Name("tickets.list")
+	r.HandleFunc("/api/v1/tickets/{id}", s.handleGetTicketByID).Methods("GET").Name("tickets.get")
+	r.HandleFunc("/api/v1/tickets/book", s.handleBookTicket).Methods("POST").Name("tickets.book")
+	r.HandleFunc("/api/v1/tickets/hold", s.handleHoldTicket).Methods("POST").Name("tickets.hold")
+	r.HandleFunc("/api/v1/tickets/{id}/confirm", s.handleConfirmHold).Methods("POST").Name("tickets.confirm")
+	r.HandleFunc("/api/v1/checkin", s.handleCheckInOnline).Methods("POST").Name("tickets.checkin")
+	r.HandleFunc("/api/v1/tickets/{id}/change", s.handleChangeTicket).Methods("POST").Name("tickets.change")
+	r.HandleFunc("/api/v1/tickets/{id}/cancel", s.handleCancelTicket).Methods("POST").Name("tickets.cancel")
+
+	r.HandleFunc("/api/v1/tickets/create", s.handleCreateTicket).Methods("POST").Name("tickets.create")
+	r.HandleFunc("/api/v1/tickets/{id}/update", s.handleUpdateTicket).Methods("POST").Name("tickets.update")
+	r.HandleFunc("/api/v1/tickets/{id}/delete", s.handleDeleteTicket).Methods("DELETE").Name("tickets.delete")
+
+	r.HandleFunc("/api/v1/bookings/create", s.handleCreateBooking).Methods("POST").Name("bookings.create")
+	r.HandleFunc("/api/v1/bookings/{locator}", s.handleGetBooking).Methods("GET").Name("bookings.get")
+	r.HandleFunc("/api/v1/bookings/{locator}/cancel", s.handleCancelBooking).Methods("POST").Name("bookings.cancel")
+
+	return r
 }
 
 func (s *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var req p.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	pass, err := s.store.GetPassengerByEmail(req.Email)
+	pass, err := s.store.GetPassengerByEmail(r.Context(), req.Email)
 	if err != nil {
-		utils.ErrorLog.Println("not found")
+		if errors.Is(err, p.ErrPassengerNotFound) {
+			err = fmt.Errorf("%w: passenger not found", errInvalidCredentials)
+		}
+		s.writeError(w, r, err)
 		return
 	}
 
 	if !pass.ValidPassword(req.Password) {
-		utils.ErrorLog.Println("not authenticated")
+		s.writeError(w, r, fmt.Errorf("%w: wrong password of passenger %s", errInvalidCredentials, pass.ID))
 		return
 	}
 
-	token, err := createJWT(pass)
-	fmt.Println(token)
+	pair, err := s.tokens.Issue(r.Context(), pass)
 	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
+	s.logger.InfoContext(r.Context(), "passenger logged in", "passenger_id", pass.ID)
+
 	resp := p.LoginResponse{
-		Token: token,
-		Email: pass.Email,
+		Email:            pass.Email,
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.ExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
 	}
 
 	WriteJSON(w, http.StatusOK, resp)
 }
 
-func withJWTAuth(handlerFunc http.HandlerFunc, s p.Storage) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		utils.InfoLog.Println("calling JWT auth middleware")
-
-		tokenString := r.Header.Get("Authorization")
-
-		token, err := validateJWT(tokenString)
-		if err != nil {
-			permissionDenied(w)
-			return
-		}
-		if !token.Valid {
-			permissionDenied(w)
-			return
-		}
-		passengerID := mux.Vars(r)["id"]
-		passenger, err := s.GetPassengerByID(passengerID)
-		if err != nil {
-			permissionDenied(w)
-			return
-		}
-		claims := token.Claims.(jwt.MapClaims)
+// handleRefreshToken exchanges refresh token for a new pair of tokens, the refresh token cannot be used again.
+func (s *APIServer) handleRefreshToken(w http.ResponseWriter, r *http.Request) {
+	req := new(auth.RefreshReq)
+	if err := decodeJSON(r, req); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
-		if passenger.Number != int64(claims["passengerNum"].(float64)) {
-			permissionDenied(w)
-			return
-		}
+	if err := req.Validate(); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
-		handlerFunc(w, r)
+	pair, err := s.tokens.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
 	}
+
+	WriteJSON(w, http.StatusOK, pair)
 }
 
-func validateJWT(tokenString string) (*jwt.Token, error) {
-	secret := os.Getenv("JWT_SECRET")
+// handleJWKS returns public keys that verify access tokens, other services fetch them to verify tokens of passengers.
+func (s *APIServer) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(auth.JWKSMaxAge.Seconds())))
+	WriteJSON(w, http.StatusOK, s.tokens.JWKS())
+}
 
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
+// handleLogout revokes access token of the request and refresh tokens of its session.
+func (s *APIServer) handleLogout(w http.ResponseWriter, r *http.Request) {
+	caller, _ := principalFrom(r.Context())
 
-		return []byte(secret), nil
-	})
-}
+	if err := s.tokens.Revoke(r.Context(), caller.claims); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
-func permissionDenied(w http.ResponseWriter) {
-	WriteJSON(w, http.StatusForbidden, APIError{Error: "permission denied"})
-}
+	s.logger.InfoContext(r.Context(), "passenger logged out", "passenger_id", caller.PassengerID)
 
-// APIError creates error
-type APIError struct {
-	Error string `json:"error"`
+	WriteJSON(w, http.StatusOK, "Logged out")
 }
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"flightticketservice/pkg/auth"
+	t "flightticketservice/pkg/booking"
+	p "flightticketservice/pkg/passenger"
+
+	"github.com/gorilla/mux"
+
+	"flightticketservice/utils"
+)
+
+// principal is the authenticated account calling a route.
+type principal struct {
+	PassengerID string
+	Role        p.Role
+	claims      *auth.Claims // claims of the access token, to revoke it on logout
+}
+
+type principalKey struct{}
+
+// principalFrom returns principal authenticated by withAuthorization, false for public routes.
+func principalFrom(ctx context.Context) (principal, bool) {
+	caller, ok := ctx.Value(principalKey{}).(principal)
+	return caller, ok
+}
+
+// policy lists roles allowed to call a route, public routes are called without a token.
+// Passengers are allowed only to their own profile, tickets and bookings, handlers check it with authorizePassenger.
+type policy struct {
+	public bool
+	roles  []p.Role
+}
+
+var public = policy{public: true}
+
+func allow(roles ...p.Role) policy {
+	return policy{roles: roles}
+}
+
+// routePolicies maps route names to policies, routes missing here are denied to everyone.
+var routePolicies = map[string]policy{
+	"swagger":      public,
+	"metrics":      public,
+	"auth.jwks":    public,
+	"auth.login":   public,
+	"auth.refresh": public,
+	"auth.logout":  allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+
+	"flights.list":        public,
+	"flights.search":      public,
+	"flights.itineraries": public,
+	"flights.get":         public,
+	"flights.seats":       public,
+	"flights.create":      allow(p.RoleAdmin),
+	"flights.update":      allow(p.RoleAdmin),
+	"flights.delete":      allow(p.RoleAdmin),
+
+	"passengers.list":   allow(p.RoleAgent, p.RoleAdmin),
+	"passengers.get":    allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"passengers.create": public,
+	"passengers.update": allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"passengers.role":   allow(p.RoleAdmin),
+	"passengers.delete": allow(p.RolePassenger, p.RoleAdmin),
+
+	"tickets.list":    allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"tickets.get":     allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"tickets.book":    allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"tickets.hold":    allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"tickets.confirm": allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"tickets.checkin": allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"tickets.change":  allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"tickets.cancel":  allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"tickets.create":  allow(p.RoleAgent, p.RoleAdmin),
+	"tickets.update":  allow(p.RoleAgent, p.RoleAdmin),
+	"tickets.delete":  allow(p.RoleAdmin),
+	"bookings.create": allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"bookings.get":    allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+	"bookings.cancel": allow(p.RolePassenger, p.RoleAgent, p.RoleAdmin),
+}
+
+// withAuthorization checks the caller of the matched route against its policy in routePolicies.
+// Token of the Authorization header identifies the caller, who is then available with principalFrom.
+// Requests matching no route are passed on to be answered with 404 or 405.
+func (s *APIServer) withAuthorization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rule, ok := routePolicies[route.GetName()]
+		if !ok {
+			s.writeError(w, r, fmt.Errorf("%w: route %q has no policy", errPermissionDenied, route.GetName()))
+			return
+		}
+		if rule.public {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		caller, err := s.authenticate(r)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		if !slices.Contains(rule.roles, caller.Role) {
+			s.writeError(w, r, fmt.Errorf("%w: role %s", errPermissionDenied, caller.Role))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), principalKey{}, caller)
+		ctx = utils.WithLogAttrs(ctx, "caller_id", caller.PassengerID, "caller_role", string(caller.Role))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticate returns principal of access token in Authorization header, with or without Bearer scheme.
+func (s *APIServer) authenticate(r *http.Request) (principal, error) {
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	claims, err := s.tokens.Verify(r.Context(), accessToken)
+	if err != nil {
+		return principal{}, err
+	}
+
+	return principal{PassengerID: claims.Subject, Role: claims.Role, claims: claims}, nil
+}
+
+// authorizePassenger returns errPermissionDenied if a passenger acts for any other passenger than themselves,
+// agents and admins act on behalf of every passenger. The IDs wrapped around the error are only logged by writeError.
+func authorizePassenger(ctx context.Context, passengerIDs ...string) error {
+	caller, ok := principalFrom(ctx)
+	if !ok {
+		return errPermissionDenied
+	}
+	if caller.Role != p.RolePassenger {
+		return nil
+	}
+
+	for _, passengerID := range passengerIDs {
+		if passengerID != caller.PassengerID {
+			return fmt.Errorf("%w: passenger %s acts for passenger %s", errPermissionDenied, caller.PassengerID, passengerID)
+		}
+	}
+	return nil
+}
+
+// authorizeTicket checks that a passenger calls for their own ticket, the ticket is loaded only for passengers.
+func authorizeTicket(ctx context.Context, tickets t.BookingService, ticketID string) error {
+	if caller, ok := principalFrom(ctx); ok && caller.Role != p.RolePassenger {
+		return nil
+	}
+
+	ticket, err := tickets.GetTicketByID(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+	return authorizePassenger(ctx, ticket.PassengerID)
+}
+
+// authorizeBooking checks that a passenger calls for booking they travel on.
+func authorizeBooking(ctx context.Context, record *t.BookingRecord) error {
+	caller, ok := principalFrom(ctx)
+	if !ok {
+		return errPermissionDenied
+	}
+	if caller.Role != p.RolePassenger {
+		return nil
+	}
+
+	for _, ticket := range record.Tickets {
+		if ticket.PassengerID == caller.PassengerID {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: passenger %s is not on booking %s", errPermissionDenied, caller.PassengerID, record.Locator)
+}
+
+// ensureAdmin creates admin account with email and password, or makes the existing account with email admin,
+// so there is an admin to manage flights and roles of other accounts.
+func ensureAdmin(ctx context.Context, store p.Storage, email, password string) (*p.Passenger, error) {
+	admin, err := store.GetPassengerByEmail(ctx, email)
+	switch {
+	case errors.Is(err, p.ErrPassengerNotFound):
+		req := &p.CreatePassengerReq{FirstName: "Admin", LastName: "Admin", Email: email, Password: password}
+		if err := req.Validate(); err != nil {
+			return nil, err
+		}
+		if admin, err = p.NewPassenger(req.FirstName, req.LastName, req.Email, req.Password); err != nil {
+			return nil, err
+		}
+		admin.Role = p.RoleAdmin
+		return admin, store.CreatePassenger(ctx, admin)
+	case err != nil:
+		return nil, err
+	case admin.Role != p.RoleAdmin:
+		admin.Role = p.RoleAdmin
+		return admin, store.SetPassengerRole(ctx, admin.ID, p.RoleAdmin)
+	}
+	return admin, nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"flightticketservice/pkg/auth"
+	t "flightticketservice/pkg/booking"
+	f "flightticketservice/pkg/flights"
+	"flightticketservice/pkg/pagination"
+	p "flightticketservice/pkg/passenger"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryRouteHasPolicy(tt *testing.T) {
+	s := newTestServer()
+
+	names := map[string]bool{}
+	err := s.Router().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		names[route.GetName()] = true
+		_, ok := routePolicies[route.GetName()]
+		assert.True(tt, ok, "route %q has no policy", route.GetName())
+		return nil
+	})
+	assert.NoError(tt, err)
+
+	for name := range routePolicies {
+		assert.True(tt, names[name], "policy of unknown route %q", name)
+	}
+}
+
+func TestAuthorization(tt *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+
+	departure := time.Now().UTC().Add(48 * time.Hour)
+	flight := f.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	assert.NoError(tt, s.flights.CreateFlight(ctx, flight))
+
+	w := serveAs(s, "", http.MethodPost, "/api/v1/passengers/create",
+		`{"first_name": "John", "last_name": "Doe", "email": "john@example.com", "password": "secret-john"}`)
+	assert.Equal(tt, http.StatusCreated, w.Code)
+	jane := &p.Passenger{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Role: p.RolePassenger}
+	assert.NoError(tt, s.store.CreatePassenger(ctx, jane))
+
+	w = serveAs(s, "", http.MethodPost, "/api/v1/login", `{"email": "john@example.com", "password": "secret-john"}`)
+	assert.Equal(tt, http.StatusOK, w.Code)
+	var login p.LoginResponse
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&login))
+	john := login.Token
+	agent := tokenFor(s, &p.Passenger{ID: "00000000-0000-4000-8000-000000000900", Role: p.RoleAgent})
+
+	w = serveAs(s, "", http.MethodGet, "/api/v1/flights", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+	w = serveAs(s, "", http.MethodGet, "/api/v1/tickets", "")
+	assert.Equal(tt, http.StatusUnauthorized, w.Code)
+	assert.Equal(tt, "invalid_token", decodeProblem(tt, w).Code)
+	w = serveAs(s, john, http.MethodDelete, "/api/v1/flights/"+flight.ID+"/delete", "")
+	assert.Equal(tt, http.StatusForbidden, w.Code)
+	assert.Equal(tt, "permission_denied", decodeProblem(tt, w).Code)
+	w = serveAs(s, agent, http.MethodPost, "/api/v1/flights/create", "{}")
+	assert.Equal(tt, http.StatusForbidden, w.Code)
+
+	// agents book on behalf of passengers, passengers only for themselves
+	hold := `{"flight_id": "` + flight.ID + `", "passenger_id": "` + jane.ID + `"}`
+	w = serveAs(s, john, http.MethodPost, "/api/v1/tickets/hold", hold)
+	assert.Equal(tt, http.StatusForbidden, w.Code)
+	assert.NotContains(tt, w.Body.String(), jane.ID, "IDs of other passengers are only logged")
+	assert.Equal(tt, "permission denied", decodeProblem(tt, w).Detail)
+	w = serveAs(s, agent, http.MethodPost, "/api/v1/tickets/hold", hold)
+	assert.Equal(tt, http.StatusCreated, w.Code)
+	var ticket t.Ticket
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&ticket))
+
+	w = serveAs(s, john, http.MethodGet, "/api/v1/tickets/"+ticket.ID, "")
+	assert.Equal(tt, http.StatusForbidden, w.Code)
+	w = serveAs(s, john, http.MethodPost, "/api/v1/tickets/"+ticket.ID+"/cancel", "")
+	assert.Equal(tt, http.StatusForbidden, w.Code)
+	w = serveAs(s, tokenFor(s, jane), http.MethodGet, "/api/v1/tickets/"+ticket.ID, "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+
+	// a hold is booked only for the passenger it was held for
+	found, err := s.store.GetPassengerByEmail(ctx, "john@example.com")
+	assert.NoError(tt, err)
+	book := "/api/v1/tickets/book?ticketID=" + ticket.ID + "&flightID=" + flight.ID + "&passengerID="
+	w = serveAs(s, john, http.MethodPost, book+found.ID, "")
+	assert.Equal(tt, http.StatusForbidden, w.Code)
+	w = serveAs(s, agent, http.MethodPost, book+found.ID, "")
+	assert.Equal(tt, http.StatusConflict, w.Code)
+	assert.Equal(tt, "not_ticket_owner", decodeProblem(tt, w).Code)
+	w = serveAs(s, tokenFor(s, jane), http.MethodPost, book+jane.ID, "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+
+	w = serveAs(s, john, http.MethodGet, "/api/v1/tickets", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+	var page pagination.Page[*t.Ticket]
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&page))
+	assert.Empty(tt, page.Items)
+
+	w = serveAs(s, john, http.MethodGet, "/api/v1/passengers/"+jane.ID, "")
+	assert.Equal(tt, http.StatusForbidden, w.Code)
+
+	// only admins change roles
+	w = serveAs(s, agent, http.MethodPost, "/api/v1/passengers/"+jane.ID+"/role", `{"role": "admin"}`)
+	assert.Equal(tt, http.StatusForbidden, w.Code)
+	w = serve(s, http.MethodPost, "/api/v1/passengers/"+jane.ID+"/role", `{"role": "pilot"}`)
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+	w = serve(s, http.MethodPost, "/api/v1/passengers/"+jane.ID+"/role", `{"role": "agent"}`)
+	assert.Equal(tt, http.StatusOK, w.Code)
+	found, err = s.store.GetPassengerByID(ctx, jane.ID)
+	assert.NoError(tt, err)
+	assert.Equal(tt, p.RoleAgent, found.Role)
+}
+
+func TestLoginFailureHidesPassenger(tt *testing.T) {
+	s := newTestServer()
+
+	w := serveAs(s, "", http.MethodPost, "/api/v1/passengers/create",
+		`{"first_name": "John", "last_name": "Doe", "email": "john@example.com", "password": "secret-john"}`)
+	assert.Equal(tt, http.StatusCreated, w.Code)
+
+	// unknown email and wrong password are answered alike, the response tells neither that email is registered nor its passenger
+	for _, body := range []string{
+		`{"email": "nobody@example.com", "password": "secret-john"}`,
+		`{"email": "john@example.com", "password": "wrong"}`,
+	} {
+		w = serveAs(s, "", http.MethodPost, "/api/v1/login", body)
+		assert.Equal(tt, http.StatusUnauthorized, w.Code)
+		problem := decodeProblem(tt, w)
+		assert.Equal(tt, "invalid_credentials", problem.Code)
+		assert.Equal(tt, "invalid email or password", problem.Detail)
+	}
+}
+
+func TestEnsureAdmin(tt *testing.T) {
+	ctx := context.Background()
+	store := p.NewMemoryStore()
+
+	_, err := ensureAdmin(ctx, store, "admin@example.com", "short")
+	assert.Error(tt, err)
+
+	admin, err := ensureAdmin(ctx, store, "admin@example.com", "admin-password")
+	assert.NoError(tt, err)
+	assert.Equal(tt, p.RoleAdmin, admin.Role)
+	assert.True(tt, admin.ValidPassword("admin-password"))
+
+	john := &p.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com", Role: p.RolePassenger}
+	assert.NoError(tt, store.CreatePassenger(ctx, john))
+	promoted, err := ensureAdmin(ctx, store, "john@example.com", "")
+	assert.NoError(tt, err)
+	assert.Equal(tt, john.ID, promoted.ID)
+
+	found, err := store.GetPassengerByID(ctx, john.ID)
+	assert.NoError(tt, err)
+	assert.Equal(tt, p.RoleAdmin, found.Role)
+}
+
+func TestTokenLifecycle(tt *testing.T) {
+	s := newTestServer()
+
+	w := serveAs(s, "", http.MethodPost, "/api/v1/passengers/create",
+		`{"first_name": "John", "last_name": "Doe", "email": "john@example.com", "password": "secret-john"}`)
+	assert.Equal(tt, http.StatusCreated, w.Code)
+
+	w = serveAs(s, "", http.MethodPost, "/api/v1/login", `{"email": "john@example.com", "password": "secret-john"}`)
+	assert.Equal(tt, http.StatusOK, w.Code)
+	var login p.LoginResponse
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&login))
+	assert.NotEmpty(tt, login.RefreshToken)
+	assert.WithinDuration(tt, time.Now().Add(auth.DefaultAccessTTL), login.ExpiresAt, time.Minute)
+
+	w = serveAs(s, "", http.MethodPost, "/api/v1/token/refresh", `{"refresh_token": "`+login.RefreshToken+`"}`)
+	assert.Equal(tt, http.StatusOK, w.Code)
+	var pair auth.Pair
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&pair))
+
+	w = serveAs(s, "Bearer "+pair.AccessToken, http.MethodGet, "/api/v1/tickets", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+
+	// the first refresh token was already exchanged, using it again revokes the session
+	w = serveAs(s, "", http.MethodPost, "/api/v1/token/refresh", `{"refresh_token": "`+login.RefreshToken+`"}`)
+	assert.Equal(tt, http.StatusUnauthorized, w.Code)
+	assert.Equal(tt, "refresh_token_reused", decodeProblem(tt, w).Code)
+	w = serveAs(s, "", http.MethodPost, "/api/v1/token/refresh", `{"refresh_token": "`+pair.RefreshToken+`"}`)
+	assert.Equal(tt, http.StatusUnauthorized, w.Code)
+	assert.Equal(tt, "invalid_refresh_token", decodeProblem(tt, w).Code)
+
+	w = serveAs(s, pair.AccessToken, http.MethodPost, "/api/v1/logout", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+	w = serveAs(s, pair.AccessToken, http.MethodGet, "/api/v1/tickets", "")
+	assert.Equal(tt, http.StatusUnauthorized, w.Code)
+	assert.Equal(tt, "token_revoked", decodeProblem(tt, w).Code)
+}
+
+func TestJWKS(tt *testing.T) {
+	s := newTestServer()
+
+	w := serveAs(s, "", http.MethodGet, "/.well-known/jwks.json", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+	var jwks auth.JWKSet
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&jwks))
+	assert.Len(tt, jwks.Keys, 1)
+
+	// tokens name the published key that verifies them
+	token, _, err := jwt.NewParser().ParseUnverified(tokenFor(s, testAdmin), &auth.Claims{})
+	assert.NoError(tt, err)
+	assert.Equal(tt, jwks.Keys[0].KeyID, token.Header["kid"])
+	assert.Equal(tt, jwks.Keys[0].Algorithm, token.Method.Alg())
+}
+
+func TestDeletePassengerAuthorization(tt *testing.T) {
+	s := newTestServer()
+	ctx := context.Background()
+
+	john := &p.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com", Role: p.RolePassenger}
+	assert.NoError(tt, s.store.CreatePassenger(ctx, john))
+	jane := &p.Passenger{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Role: p.RolePassenger}
+	assert.NoError(tt, s.store.CreatePassenger(ctx, jane))
+
+	w := serveAs(s, tokenFor(s, john), http.MethodDelete, "/api/v1/passengers/"+jane.ID+"/delete", "")
+	assert.Equal(tt, http.StatusForbidden, w.Code)
+	w = serveAs(s, tokenFor(s, john), http.MethodDelete, "/api/v1/passengers/"+john.ID+"/delete", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+	w = serve(s, http.MethodDelete, "/api/v1/passengers/"+jane.ID+"/delete", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+
+	for _, passenger := range []*p.Passenger{john, jane} {
+		_, err := s.store.GetPassengerByID(ctx, passenger.ID)
+		assert.ErrorIs(tt, err, p.ErrPassengerNotFound)
+	}
+}
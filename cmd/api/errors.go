@@ -0,0 +1,119 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"flightticketservice/pkg/apperr"
+)
+
+// problemContentType is media type of error responses, see RFC 7807.
+const problemContentType = "application/problem+json"
+
+// Problem is body of every error response in RFC 7807 problem details format,
+// extended with machine-readable code, invalid fields of the request and request ID.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	Errors    []apperr.FieldError `json:"errors,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+// kindStatuses maps kinds of errors to response status.
+var kindStatuses = []struct {
+	kind   error
+	status int
+}{
+	{apperr.ErrValidation, http.StatusBadRequest},
+	{apperr.ErrUnauthorized, http.StatusUnauthorized},
+	{apperr.ErrForbidden, http.StatusForbidden},
+	{apperr.ErrNotFound, http.StatusNotFound},
+	{apperr.ErrConflict, http.StatusConflict},
+	{apperr.ErrGone, http.StatusGone},
+	{apperr.ErrUnprocessable, http.StatusUnprocessableEntity},
+}
+
+// Errors of the API itself.
+var (
+	errInvalidCredentials = apperr.New(apperr.ErrUnauthorized, "invalid_credentials", "invalid email or password")
+	errPermissionDenied   = apperr.New(apperr.ErrForbidden, "permission_denied", "permission denied")
+	errRouteNotFound      = apperr.New(apperr.ErrNotFound, "route_not_found", "no such endpoint")
+)
+
+// Codes of problems that have no error of a kind.
+const (
+	codeInternal         = "internal"
+	codeTimeout          = "timeout"
+	codeMethodNotAllowed = "method_not_allowed"
+)
+
+// writeError writes problem of err, it is the only place errors are mapped to responses.
+// Detail of the problem is the public message of err, details wrapped around it, like IDs, are only logged.
+// Errors of no kind are internal, their message is logged but not shown to the client.
+func (s *APIServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := newProblem(w, r, http.StatusInternalServerError, codeInternal, "internal server error")
+	for _, ks := range kindStatuses {
+		if errors.Is(err, ks.kind) {
+			problem = newProblem(w, r, ks.status, apperr.Code(err), cmp.Or(apperr.Message(err), ks.kind.Error()))
+			problem.Errors = apperr.Fields(err)
+			break
+		}
+	}
+	if problem.Status == http.StatusInternalServerError && errors.Is(err, context.DeadlineExceeded) {
+		problem = newProblem(w, r, http.StatusServiceUnavailable, codeTimeout, "request timed out")
+	}
+
+	if problem.Status >= http.StatusInternalServerError {
+		s.logger.ErrorContext(r.Context(), "request failed", "error", err)
+	} else {
+		s.logger.WarnContext(r.Context(), "request rejected", "code", problem.Code, "error", err)
+	}
+	writeProblem(w, problem)
+}
+
+// newProblem creates problem of the request with status, code and detail.
+func newProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: w.Header().Get(requestIDHeader),
+	}
+}
+
+// writeProblem writes problem as JSON with its status.
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// decodeJSON decodes request body into v, malformed body is a validation error.
+func decodeJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return apperr.Field("body", "invalid JSON: "+err.Error())
+	}
+	return nil
+}
+
+// required returns validation error listing names of empty parameters, nil if all are set.
+// Params are pairs of name and value.
+func required(params ...string) error {
+	var invalid apperr.ValidationError
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] == "" {
+			invalid.Add(params[i], "is required")
+		}
+	}
+	return invalid.Err()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flightticketservice/pkg/apperr"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeProblem(tt *testing.T, w *httptest.ResponseRecorder) Problem {
+	assert.Equal(tt, problemContentType, w.Header().Get("Content-Type"))
+	var problem Problem
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&problem))
+	return problem
+}
+
+func TestErrorResponses(tt *testing.T) {
+	s := newTestServer()
+
+	w := serve(s, http.MethodGet, "/api/v1/tickets/42", "")
+	assert.Equal(tt, http.StatusNotFound, w.Code)
+	problem := decodeProblem(tt, w)
+	assert.Equal(tt, "ticket_not_found", problem.Code)
+	assert.Equal(tt, http.StatusNotFound, problem.Status)
+	assert.Equal(tt, "Not Found", problem.Title)
+	assert.Equal(tt, "/api/v1/tickets/42", problem.Instance)
+	assert.Equal(tt, w.Header().Get(requestIDHeader), problem.RequestID)
+
+	w = serve(s, http.MethodPost, "/api/v1/tickets/book?ticketID=1", "")
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+	problem = decodeProblem(tt, w)
+	assert.Equal(tt, apperr.CodeValidation, problem.Code)
+	assert.Equal(tt, []apperr.FieldError{
+		{Field: "flightID", Message: "is required"},
+		{Field: "passengerID", Message: "is required"},
+	}, problem.Errors)
+
+	w = serve(s, http.MethodGet, "/api/v1/flights?sort=airline", "")
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+	assert.Equal(tt, "invalid_pagination", decodeProblem(tt, w).Code)
+
+	w = serve(s, http.MethodGet, "/api/v1/flights?orgin=MOW", "")
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+	problem = decodeProblem(tt, w)
+	assert.Equal(tt, apperr.CodeValidation, problem.Code)
+	assert.Equal(tt, []apperr.FieldError{
+		{Field: "orgin", Message: "unknown filter, use one of airline, destination, origin"},
+	}, problem.Errors)
+
+	w = serve(s, http.MethodPost, "/api/v1/flights/create", "{")
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+	assert.Equal(tt, "body", decodeProblem(tt, w).Errors[0].Field)
+
+	w = serve(s, http.MethodGet, "/api/v1/unknown", "")
+	assert.Equal(tt, http.StatusNotFound, w.Code)
+	assert.Equal(tt, "route_not_found", decodeProblem(tt, w).Code)
+
+	w = serve(s, http.MethodDelete, "/api/v1/flights", "")
+	assert.Equal(tt, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(tt, codeMethodNotAllowed, decodeProblem(tt, w).Code)
+}
+
+func TestWriteErrorHidesInternalErrors(tt *testing.T) {
+	s := newTestServer()
+
+	w := httptest.NewRecorder()
+	s.writeError(w, httptest.NewRequest(http.MethodGet, "/", nil), errors.New("pq: connection refused"))
+	assert.Equal(tt, http.StatusInternalServerError, w.Code)
+	problem := decodeProblem(tt, w)
+	assert.Equal(tt, codeInternal, problem.Code)
+	assert.NotContains(tt, problem.Detail, "pq")
+
+	w = httptest.NewRecorder()
+	s.writeError(w, httptest.NewRequest(http.MethodGet, "/", nil), context.DeadlineExceeded)
+	assert.Equal(tt, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(tt, codeTimeout, decodeProblem(tt, w).Code)
+}
+
+func TestCreateRequestValidation(tt *testing.T) {
+	s := newTestServer()
+
+	w := serve(s, http.MethodPost, "/api/v1/passengers/create",
+		`{"first_name": "Ivan", "last_name": "Petrov", "email": "ivan", "password": ""}`)
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+	assert.Equal(tt, []apperr.FieldError{
+		{Field: "email", Message: "must be a valid email address"},
+		{Field: "password", Message: "is required"},
+	}, decodeProblem(tt, w).Errors)
+
+	w = serve(s, http.MethodPost, "/api/v1/flights/create", `{"airline": "S7", "origin": "MOW", "destination": "MOW",
+		"departure": "2030-05-01T10:00:00Z", "arrival": "2030-05-01T09:00:00Z", "price": -5}`)
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+	problem := decodeProblem(tt, w)
+	assert.Equal(tt, apperr.CodeValidation, problem.Code)
+	assert.Equal(tt, []apperr.FieldError{
+		{Field: "destination", Message: "must differ from origin"},
+		{Field: "arrival", Message: "must be after departure"},
+		{Field: "price", Message: "must be greater than 0"},
+	}, problem.Errors)
+}
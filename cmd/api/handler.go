@@ -2,16 +2,17 @@ package main
 
 import (
 	"encoding/json"
+	"flightticketservice/pkg/apperr"
 	t "flightticketservice/pkg/booking"
 	f "flightticketservice/pkg/flights"
+	"flightticketservice/pkg/pagination"
 	p "flightticketservice/pkg/passenger"
+	"fmt"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
 	"time"
 
-	"flightticketservice/utils"
-
-	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
 )
 
@@ -19,83 +20,278 @@ import (
 
 // handleGetFlights handles requests for getting list of flights.
 func (s *APIServer) handleGetFlights(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("GetFlights called")
+	params, err := pageParams(r.URL.Query())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
-	flights, err := s.flights.GetFlights()
+	flights, err := s.flights.GetFlights(r.Context(), params)
 
 	if err != nil {
-		utils.ErrorLog.Printf("Error receiving flights: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
 	WriteJSON(w, http.StatusOK, flights)
 }
 
+// pageParams reads limit, cursor and sort from query string, other parameters become filters,
+// filters the list does not support are rejected when the page is fetched.
+func pageParams(query url.Values) (pagination.Params, error) {
+	params := pagination.Params{
+		Cursor:  query.Get("cursor"),
+		Sort:    query.Get("sort"),
+		Filters: map[string]string{},
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return params, apperr.Field("limit", "must be a number")
+		}
+		params.Limit = limit
+	}
+
+	for name := range query {
+		switch name {
+		case "limit", "cursor", "sort":
+		default:
+			params.Filters[name] = query.Get(name)
+		}
+	}
+
+	return params, nil
+}
+
 // handleGetFlightByParams handles requests for getting list of flights by parameters.
 func (s *APIServer) handleGetFlightByParams(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("GetFlightsByParams called")
-
 	query := r.URL.Query()
-	origin := query.Get("origin")
-	destination := query.Get("destination")
+	if query.Has("max_stops") {
+		s.handleSearchConnections(w, r)
+		return
+	}
 
-	departure, err := time.Parse(time.RFC3339, query.Get("departure"))
+	searchParams, err := parseSearchParams(query)
 	if err != nil {
-		utils.ErrorLog.Printf("Error in time format: %v", err)
-		http.Error(w, "Invalid departure time format. Use RFC3339.", http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
-	arrival, err := time.Parse(time.RFC3339, query.Get("arrival"))
+
+	flights, err := s.flights.GetFlightsByParams(r.Context(), searchParams)
+
 	if err != nil {
-		utils.ErrorLog.Printf("Error in time format: %v", err)
-		http.Error(w, "Invalid arrival time format. Use RFC3339.", http.StatusBadRequest)
+		s.writeError(w, r, err)
 		return
 	}
 
-	searchParams := f.SearchParams{
-		Origin:      origin,
-		Destination: destination,
-		Departure:   departure,
-		Arrival:     arrival,
+	WriteJSON(w, http.StatusOK, flights)
+}
+
+// parseSearchParams reads flight search parameters from query string.
+func parseSearchParams(query url.Values) (f.SearchParams, error) {
+	params := f.SearchParams{
+		Origin:      query.Get("origin"),
+		Destination: query.Get("destination"),
+		Airline:     query.Get("airline"),
+		SortBy:      query.Get("sort"),
+	}
+
+	var err error
+	if value := query.Get("departure"); value != "" {
+		if params.Departure, err = time.Parse(time.RFC3339, value); err != nil {
+			return params, apperr.Field("departure", "invalid time format, use RFC3339")
+		}
+	}
+	if value := query.Get("arrival"); value != "" {
+		if params.Arrival, err = time.Parse(time.RFC3339, value); err != nil {
+			return params, apperr.Field("arrival", "invalid time format, use RFC3339")
+		}
+	}
+	if value := query.Get("departure_date"); value != "" {
+		if params.DepartureDate, err = time.Parse(time.DateOnly, value); err != nil {
+			return params, apperr.Field("departure_date", "invalid date format, use YYYY-MM-DD")
+		}
+	}
+	if value := query.Get("flex_days"); value != "" {
+		if params.FlexDays, err = strconv.Atoi(value); err != nil {
+			return params, apperr.Field("flex_days", "must be a number")
+		}
+	}
+	if value := query.Get("departure_time_from"); value != "" {
+		if params.DepartureTimeFrom, err = parseTimeOfDay(value); err != nil {
+			return params, apperr.Field("departure_time_from", "invalid format, use HH:MM")
+		}
+	}
+	if value := query.Get("departure_time_to"); value != "" {
+		if params.DepartureTimeTo, err = parseTimeOfDay(value); err != nil {
+			return params, apperr.Field("departure_time_to", "invalid format, use HH:MM")
+		}
+	}
+	if value := query.Get("min_price"); value != "" {
+		if params.MinPrice, err = strconv.ParseFloat(value, 64); err != nil {
+			return params, apperr.Field("min_price", "must be a number")
+		}
+	}
+	if value := query.Get("max_price"); value != "" {
+		if params.MaxPrice, err = strconv.ParseFloat(value, 64); err != nil {
+			return params, apperr.Field("max_price", "must be a number")
+		}
 	}
 
-	flights, err := s.flights.GetFlightsByParams(searchParams)
+	if err := params.Validate(); err != nil {
+		return params, apperr.Invalid(err)
+	}
+	return params, nil
+}
 
+// parseTimeOfDay parses "HH:MM" to duration since midnight.
+func parseTimeOfDay(value string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", value)
 	if err != nil {
-		utils.ErrorLog.Printf("Error receiving flights: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, err
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// handleSearchConnections handles flight search with stops, called by handleGetFlightByParams
+// when max_stops parameter is present.
+func (s *APIServer) handleSearchConnections(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := f.ConnectionParams{
+		Origin:      query.Get("origin"),
+		Destination: query.Get("destination"),
+		SortBy:      query.Get("sort"),
+	}
+
+	var err error
+	if params.MaxStops, err = strconv.Atoi(query.Get("max_stops")); err != nil {
+		s.writeError(w, r, apperr.Field("max_stops", "must be a number"))
+		return
+	}
+	if params.DepartureDate, err = time.Parse(time.DateOnly, query.Get("departure_date")); err != nil {
+		s.writeError(w, r, apperr.Field("departure_date", "invalid date format, use YYYY-MM-DD"))
+		return
+	}
+	if value := query.Get("min_connection"); value != "" {
+		if params.MinConnection, err = time.ParseDuration(value); err != nil {
+			s.writeError(w, r, apperr.Field("min_connection", "invalid duration, use duration like 45m"))
+			return
+		}
+	}
+	if value := query.Get("max_layover"); value != "" {
+		if params.MaxLayover, err = time.ParseDuration(value); err != nil {
+			s.writeError(w, r, apperr.Field("max_layover", "invalid duration, use duration like 6h"))
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if params.Limit, err = strconv.Atoi(value); err != nil {
+			s.writeError(w, r, apperr.Field("limit", "must be a number"))
+			return
+		}
+	}
+
+	if err := params.Validate(); err != nil {
+		s.writeError(w, r, apperr.Invalid(err))
 		return
 	}
 
-	WriteJSON(w, http.StatusOK, flights)
+	itineraries, err := f.SearchConnections(r.Context(), s.flights, params)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, itineraries)
+}
+
+// handleSearchItineraries handles requests for round trip and multi-city search.
+// @Summary Search itineraries
+// @Description Finds priced combinations of flights for round trip or multi-city legs, cheapest first.
+// @Tags flights
+// @Accept json
+// @Produce json
+// @Param itinerary body flights.ItineraryReq true "Legs with origin, destination and date"
+// @Success 200 {array} flights.Itinerary
+// @Failure 400 "Invalid legs"
+// @Router /api/v1/flights/itineraries [post]
+func (s *APIServer) handleSearchItineraries(w http.ResponseWriter, r *http.Request) {
+	itineraryReq := new(f.ItineraryReq)
+	if err := decodeJSON(r, itineraryReq); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if _, err := itineraryReq.SearchParams(); err != nil {
+		s.writeError(w, r, apperr.Invalid(err))
+		return
+	}
+
+	itineraries, err := f.SearchItineraries(r.Context(), s.flights, itineraryReq)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, itineraries)
 }
 
 // handleGetFlightByID handles requests for getting flight info.
 func (s *APIServer) handleGetFlightByID(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("GetFlightInfo called")
-
 	vars := mux.Vars(r)
 	flightID := vars["id"]
 
-	flight, err := s.flights.GetFlightByID(flightID)
+	flight, err := s.flights.GetFlightByID(r.Context(), flightID)
 
 	if err != nil {
-		utils.ErrorLog.Printf("Error receiving flight: %v", err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		s.writeError(w, r, err)
 		return
 	}
 
 	WriteJSON(w, http.StatusOK, flight)
 }
 
+// handleGetFlightSeats handles requests for getting seat availability of the flight.
+// @Summary Get flight seats
+// @Description Returns seat map of the flight with availability of every seat.
+// @Tags flights
+// @Accept json
+// @Produce json
+// @Param id path string true "Unique identifier of the flight"
+// @Success 200 {array} flights.SeatAvailability
+// @Failure 404 "Flight not found"
+// @Router /api/v1/flights/{id}/seats [get]
+func (s *APIServer) handleGetFlightSeats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	flightID := vars["id"]
+
+	flight, err := s.flights.GetFlightByID(ctx, flightID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	taken, err := s.tickets.GetTakenSeats(ctx, flightID)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, flight.SeatMap.Availability(taken))
+}
+
 // handleCreateFlight handles requests for creating flight.
 func (s *APIServer) handleCreateFlight(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("CreateFlight called")
-
 	createFlightReq := new(f.CreateFlightReq)
-	if err := json.NewDecoder(r.Body).Decode(createFlightReq); err != nil {
-		utils.ErrorLog.Fatal("Cannot decode flight data")
+	if err := decodeJSON(r, createFlightReq); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := createFlightReq.Validate(); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -108,32 +304,39 @@ func (s *APIServer) handleCreateFlight(w http.ResponseWriter, r *http.Request) {
 		createFlightReq.Price,
 	)
 
-	utils.InfoLog.Println("new flight: ", newFlight, " created")
+	if err := newFlight.ApplyLayout(createFlightReq.SeatMap, createFlightReq.Capacity); err != nil {
+		s.writeError(w, r, apperr.Invalid(err))
+		return
+	}
 
-	if err := s.flights.CreateFlight(newFlight); err != nil {
-		utils.ErrorLog.Printf("Error in CreateFlight: %v", err)
+	if err := s.flights.CreateFlight(r.Context(), newFlight); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
+	s.logger.InfoContext(r.Context(), "flight created", "flight_id", newFlight.ID)
+
 	WriteJSON(w, http.StatusCreated, "Flight created")
 }
 
 // handleUpdateFlight handles requests for updating flight.
 func (s *APIServer) handleUpdateFlight(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("UpdateFlight called")
-
 	vars := mux.Vars(r)
 	passengerID := vars["id"]
 
-	if passengerID == "" {
-		utils.ErrorLog.Printf("Missing required parameters in UpdateFlight query")
-		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+	if err := required("id", passengerID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
 	createFlightReq := new(f.CreateFlightReq)
-	if err := json.NewDecoder(r.Body).Decode(createFlightReq); err != nil {
-		utils.ErrorLog.Fatal("Cannot decode flight data")
+	if err := decodeJSON(r, createFlightReq); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := createFlightReq.Validate(); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -146,32 +349,33 @@ func (s *APIServer) handleUpdateFlight(w http.ResponseWriter, r *http.Request) {
 		createFlightReq.Price,
 	)
 
-	utils.InfoLog.Println("Flight: ", newFlight, " updated")
+	if err := newFlight.ApplyLayout(createFlightReq.SeatMap, createFlightReq.Capacity); err != nil {
+		s.writeError(w, r, apperr.Invalid(err))
+		return
+	}
 
-	if err := s.flights.UpdateFlight(passengerID, newFlight); err != nil {
-		utils.ErrorLog.Printf("Error in UpdateFlight: %v", err)
+	if err := s.flights.UpdateFlight(r.Context(), passengerID, newFlight); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
+	s.logger.InfoContext(r.Context(), "flight updated", "flight_id", passengerID)
+
 	WriteJSON(w, http.StatusOK, "Flight updated")
 }
 
 // handleDeleteFlight handles requests for deleting flight.
 func (s *APIServer) handleDeleteFlight(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("DeleteFlight called")
-
 	vars := mux.Vars(r)
 	flightID := vars["id"]
 
-	if flightID == "" {
-		utils.ErrorLog.Printf("flight id is empty")
-		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+	if err := required("id", flightID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	if err := s.flights.DeleteFlight(flightID); err != nil {
-		utils.ErrorLog.Printf("Error in DeleteFlight: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := s.flights.DeleteFlight(r.Context(), flightID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -182,26 +386,101 @@ func (s *APIServer) handleDeleteFlight(w http.ResponseWriter, r *http.Request) {
 
 // handleBookTicket handles requests for booking flight.
 func (s *APIServer) handleBookTicket(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("BookTicket called")
-
 	queryParams := r.URL.Query()
-	utils.InfoLog.Println(queryParams)
+	s.logger.DebugContext(r.Context(), "query parameters", "query", queryParams)
 	ticketID := queryParams.Get("ticketID")
 	flightID := queryParams.Get("flightID")
 	passengerID := queryParams.Get("passengerID")
 	additionalInfo := queryParams.Get("additionalInfo")
 
-	if passengerID == "" || ticketID == "" || flightID == "" {
-		utils.ErrorLog.Printf("Missing required parameters in BookTicket query")
-		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+	if err := required("ticketID", ticketID, "flightID", flightID, "passengerID", passengerID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizePassenger(r.Context(), passengerID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizeTicket(r.Context(), s.tickets, ticketID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	err := s.tickets.BookTicket(ticketID, flightID, passengerID, additionalInfo)
+	err := s.tickets.BookTicket(r.Context(), ticketID, flightID, passengerID, additionalInfo)
 
 	if err != nil {
-		utils.ErrorLog.Printf("Error in BookTicket: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "Ticket booked")
+}
+
+// handleHoldTicket handles requests for holding a seat before purchase.
+func (s *APIServer) handleHoldTicket(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	holdReq := new(t.HoldTicketReq)
+	if err := decodeJSON(r, holdReq); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := holdReq.Validate(); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizePassenger(ctx, holdReq.PassengerID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var ticket *t.Ticket
+	err := s.uow.Do(ctx, func(st stores) error {
+		flight, err := st.flights.GetFlightByID(ctx, holdReq.FlightID)
+		if err != nil {
+			return err
+		}
+
+		seatNumber, err := canonicalSeat(flight, holdReq.SeatNumber)
+		if err != nil {
+			return err
+		}
+
+		ticket = t.CreateNewTicket(
+			flight.ID,
+			holdReq.PassengerID,
+			t.StatusHeld,
+			seatNumber,
+			holdReq.AdditionalInfo,
+			flight.Departure,
+			flight.Arrival,
+		)
+
+		return st.tickets.HoldTicket(ctx, ticket, s.holdTTL)
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, ticket)
+}
+
+// handleConfirmHold handles requests for confirming a held seat.
+func (s *APIServer) handleConfirmHold(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ticketID := vars["id"]
+
+	if err := authorizeTicket(r.Context(), s.tickets, ticketID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.tickets.ConfirmHold(r.Context(), ticketID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -210,33 +489,97 @@ func (s *APIServer) handleBookTicket(w http.ResponseWriter, r *http.Request) {
 
 // handleCheckInOnline handles requests for online registration.
 func (s *APIServer) handleCheckInOnline(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("CheckInOnline called")
+	ctx := r.Context()
+	checkInReq := new(t.CheckInReq)
+	if err := decodeJSON(r, checkInReq); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := required("ticket_id", checkInReq.TicketID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
-	WriteJSON(w, http.StatusOK, "")
+	var ticket *t.Ticket
+	err := s.uow.Do(ctx, func(st stores) error {
+		found, err := st.tickets.GetTicketByID(ctx, checkInReq.TicketID)
+		if err != nil {
+			return err
+		}
+
+		if err := authorizePassenger(ctx, found.PassengerID); err != nil {
+			return err
+		}
+
+		flight, err := st.flights.GetFlightByID(ctx, found.FlightID)
+		if err != nil {
+			return err
+		}
+
+		seatNumber, err := canonicalSeat(flight, checkInReq.SeatNumber)
+		if err != nil {
+			return err
+		}
+
+		ticket, err = st.tickets.CheckIn(ctx, checkInReq.TicketID, seatNumber, flight.Departure)
+		return err
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, ticket)
+}
+
+// canonicalSeat returns canonical seat number if seat exists on the flight.
+func canonicalSeat(flight *f.Flight, seatNumber string) (string, error) {
+	if seatNumber == "" {
+		return "", nil
+	}
+
+	seat, ok := flight.SeatMap.Seat(seatNumber)
+	if !ok {
+		return "", fmt.Errorf("%w: %s on flight %s", f.ErrSeatNotFound, seatNumber, flight.ID)
+	}
+
+	return seat.Number, nil
 }
 
 // handleChangeTicket handles requests for changing tickets.
 func (s *APIServer) handleChangeTicket(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("ChangeTicket called")
-
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	ticketID := vars["id"]
 	flightID := r.URL.Query().Get("flightID")
 
 	queryParams := r.URL.Query()
-	utils.InfoLog.Println(queryParams)
+	s.logger.DebugContext(ctx, "query parameters", "query", queryParams)
 
-	if ticketID == "" {
-		utils.ErrorLog.Printf("Missing required parameters in ChangeTicket query")
-		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+	if err := required("id", ticketID, "flightID", flightID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	err := s.tickets.ChangeFlight(ticketID, flightID)
+	err := s.uow.Do(ctx, func(st stores) error {
+		ticket, err := st.tickets.GetTicketByID(ctx, ticketID)
+		if err != nil {
+			return err
+		}
+
+		if err := authorizePassenger(ctx, ticket.PassengerID); err != nil {
+			return err
+		}
+
+		if _, err := st.seatNumber(ctx, flightID, ticket.SeatNumber); err != nil {
+			return err
+		}
 
+		return st.tickets.ChangeFlight(ctx, ticketID, flightID)
+	})
 	if err != nil {
-		utils.ErrorLog.Printf("Error in ChangeTicket: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -245,22 +588,23 @@ func (s *APIServer) handleChangeTicket(w http.ResponseWriter, r *http.Request) {
 
 // handleCancelTicket handles requests for ticket cancellation.
 func (s *APIServer) handleCancelTicket(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("CancelTicket called")
-
 	vars := mux.Vars(r)
 	ticketID := vars["id"]
 
-	if ticketID == "" {
-		utils.ErrorLog.Printf("ticket id is empty")
-		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+	if err := required("id", ticketID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizeTicket(r.Context(), s.tickets, ticketID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	err := s.tickets.CancelTicket(ticketID)
+	err := s.tickets.CancelTicket(r.Context(), ticketID)
 
 	if err != nil {
-		utils.ErrorLog.Printf("Error in CancelTicket: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -269,13 +613,21 @@ func (s *APIServer) handleCancelTicket(w http.ResponseWriter, r *http.Request) {
 
 // handleGetTickets handles requests for getting list of tickets.
 func (s *APIServer) handleGetTickets(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("GetTickets called")
+	params, err := pageParams(r.URL.Query())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// passengers list only their own tickets
+	if caller, _ := principalFrom(r.Context()); caller.Role == p.RolePassenger {
+		params.Filters["passenger_id"] = caller.PassengerID
+	}
 
-	tickets, err := s.tickets.GetTickets()
+	tickets, err := s.tickets.GetTickets(r.Context(), params)
 
 	if err != nil {
-		utils.ErrorLog.Printf("Error receiving tickets: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -284,15 +636,17 @@ func (s *APIServer) handleGetTickets(w http.ResponseWriter, r *http.Request) {
 
 // handleGetTicketByID handles requests for getting ticket info.
 func (s *APIServer) handleGetTicketByID(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("GetTicketInfo called")
-
 	vars := mux.Vars(r)
 	ticketID := vars["id"]
 
-	tickets, err := s.tickets.GetTicketByID(ticketID)
+	tickets, err := s.tickets.GetTicketByID(r.Context(), ticketID)
 	if err != nil {
-		utils.ErrorLog.Printf("Error receiving tickets: %v", err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizePassenger(r.Context(), tickets.PassengerID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -301,37 +655,46 @@ func (s *APIServer) handleGetTicketByID(w http.ResponseWriter, r *http.Request)
 
 // handleUpdateTicket handles requests for updating ticket info
 func (s *APIServer) handleUpdateTicket(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("UpdateTicket called")
-
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	ticketID := vars["id"]
 
-	if ticketID == "" {
-		utils.ErrorLog.Printf("Missing required parameters in UpdateTicket query")
-		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+	if err := required("id", ticketID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
 	createTicketReq := new(t.CreateTicketReq)
-	if err := json.NewDecoder(r.Body).Decode(createTicketReq); err != nil {
-		utils.ErrorLog.Fatal("Cannot decode ticket data")
+	if err := decodeJSON(r, createTicketReq); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	newTicket := t.CreateNewTicket(
-		createTicketReq.FlightID,
-		createTicketReq.PassengerID,
-		"updated", // status
-		createTicketReq.SeatNumber,
-		createTicketReq.AdditionalInfo,
-		createTicketReq.DepartureTime,
-		createTicketReq.ArrivalTime,
-	)
-
-	utils.InfoLog.Println("Flight: ", newTicket, " updated")
+	if err := createTicketReq.Validate(); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
-	if err := s.tickets.UpdateTicket(ticketID, newTicket); err != nil {
-		utils.ErrorLog.Printf("Error in UpdateTicket: %v", err)
+	err := s.uow.Do(ctx, func(st stores) error {
+		seatNumber, err := st.seatNumber(ctx, createTicketReq.FlightID, createTicketReq.SeatNumber)
+		if err != nil {
+			return err
+		}
+
+		newTicket := t.CreateNewTicket(
+			createTicketReq.FlightID,
+			createTicketReq.PassengerID,
+			"", // keep current status
+			seatNumber,
+			createTicketReq.AdditionalInfo,
+			createTicketReq.DepartureTime,
+			createTicketReq.ArrivalTime,
+		)
+
+		return st.tickets.UpdateTicket(ctx, ticketID, newTicket)
+	})
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -340,28 +703,39 @@ func (s *APIServer) handleUpdateTicket(w http.ResponseWriter, r *http.Request) {
 
 // handleCreateTicket handles requests for updating ticket info
 func (s *APIServer) handleCreateTicket(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("CreateTicket called")
-
+	ctx := r.Context()
 	createTicketReq := new(t.CreateTicketReq)
-	if err := json.NewDecoder(r.Body).Decode(createTicketReq); err != nil {
-		utils.ErrorLog.Fatal("Cannot decode passenger data")
+	if err := decodeJSON(r, createTicketReq); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	newTicket := t.CreateNewTicket(
-		createTicketReq.FlightID,
-		createTicketReq.PassengerID,
-		"created", // status
-		createTicketReq.SeatNumber,
-		createTicketReq.AdditionalInfo,
-		createTicketReq.DepartureTime,
-		createTicketReq.ArrivalTime,
-	)
-
-	utils.InfoLog.Println("New ticket: ", newTicket, " created")
+	if err := createTicketReq.Validate(); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
-	if err := s.tickets.CreateTicket(newTicket); err != nil {
-		utils.ErrorLog.Printf("Error in CreateTicket: %v", err)
+	err := s.uow.Do(ctx, func(st stores) error {
+		seatNumber, err := st.seatNumber(ctx, createTicketReq.FlightID, createTicketReq.SeatNumber)
+		if err != nil {
+			return err
+		}
+
+		newTicket := t.CreateNewTicket(
+			createTicketReq.FlightID,
+			createTicketReq.PassengerID,
+			t.StatusHeld,
+			seatNumber,
+			createTicketReq.AdditionalInfo,
+			createTicketReq.DepartureTime,
+			createTicketReq.ArrivalTime,
+		)
+
+		// created tickets are holds, they are released like any hold unless booked before ttl
+		return st.tickets.HoldTicket(ctx, newTicket, s.holdTTL)
+	})
+	if err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -370,36 +744,156 @@ func (s *APIServer) handleCreateTicket(w http.ResponseWriter, r *http.Request) {
 
 // handleUpdateTicket handles requests for deleting ticket info
 func (s *APIServer) handleDeleteTicket(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("DeleteTicket called")
-
 	vars := mux.Vars(r)
 	ticketID := vars["id"]
 
-	if ticketID == "" {
-		utils.ErrorLog.Printf("flight id is empty")
-		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+	if err := required("id", ticketID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	if err := s.tickets.DeleteTicket(ticketID); err != nil {
-		utils.ErrorLog.Printf("Error in DeleteTicket: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := s.tickets.DeleteTicket(r.Context(), ticketID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 	WriteJSON(w, http.StatusOK, "Ticket deleted")
 }
 
+// Bookings
+
+// handleCreateBooking handles requests for booking several passengers and segments under one locator.
+func (s *APIServer) handleCreateBooking(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	createBookingReq := new(t.CreateBookingReq)
+	if err := decodeJSON(r, createBookingReq); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := createBookingReq.Validate(); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizePassenger(ctx, createBookingReq.PassengerIDs...); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	var record *t.BookingRecord
+	err := s.uow.Do(ctx, func(st stores) error {
+		for _, passengerID := range createBookingReq.PassengerIDs {
+			if _, err := st.passengers.GetPassengerByID(ctx, passengerID); err != nil {
+				return err
+			}
+		}
+
+		tickets := []*t.Ticket{}
+		for _, segment := range createBookingReq.Segments {
+			flight, err := st.flights.GetFlightByID(ctx, segment.FlightID)
+			if err != nil {
+				return err
+			}
+
+			for i, passengerID := range createBookingReq.PassengerIDs {
+				seat := ""
+				if len(segment.Seats) != 0 {
+					if seat, err = canonicalSeat(flight, segment.Seats[i]); err != nil {
+						return err
+					}
+				}
+
+				tickets = append(tickets, t.CreateNewTicket(
+					flight.ID,
+					passengerID,
+					t.StatusBooked,
+					seat,
+					createBookingReq.AdditionalInfo,
+					flight.Departure,
+					flight.Arrival,
+				))
+			}
+		}
+
+		var err error
+		record, err = st.tickets.CreateBooking(ctx, tickets)
+		return err
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusCreated, record)
+}
+
+// handleGetBooking handles requests for getting booking by locator and last name.
+func (s *APIServer) handleGetBooking(w http.ResponseWriter, r *http.Request) {
+	locator := mux.Vars(r)["locator"]
+	lastName := r.URL.Query().Get("last_name")
+
+	if err := required("last_name", lastName); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	record, err := s.tickets.GetBooking(r.Context(), locator, lastName)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizeBooking(r.Context(), record); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, record)
+}
+
+// handleCancelBooking handles requests for cancelling every ticket of the booking.
+func (s *APIServer) handleCancelBooking(w http.ResponseWriter, r *http.Request) {
+	locator := mux.Vars(r)["locator"]
+	lastName := r.URL.Query().Get("last_name")
+
+	if err := required("last_name", lastName); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	record, err := s.tickets.GetBooking(r.Context(), locator, lastName)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizeBooking(r.Context(), record); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.tickets.CancelBooking(r.Context(), locator, lastName); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, "Booking cancelled")
+}
+
 // Passengers
 
 // handleGetPassengers handles requests for getting list of passengers.
 func (s *APIServer) handleGetPassengers(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("GetPassengers called")
+	params, err := pageParams(r.URL.Query())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
 
-	passengers, err := s.store.GetPassengers()
+	passengers, err := s.store.GetPassengers(r.Context(), params)
 
 	if err != nil {
-		utils.ErrorLog.Printf("Error receiving passengers: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -408,16 +902,18 @@ func (s *APIServer) handleGetPassengers(w http.ResponseWriter, r *http.Request)
 
 // handleGetPassengerByID handles requests for getting passenger by id.
 func (s *APIServer) handleGetPassengerByID(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("GetPassengerByID called")
-
 	vars := mux.Vars(r)
 	passengerID := vars["id"]
 
-	passenger, err := s.store.GetPassengerByID(passengerID)
+	if err := authorizePassenger(r.Context(), passengerID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	passenger, err := s.store.GetPassengerByID(r.Context(), passengerID)
 
 	if err != nil {
-		utils.ErrorLog.Printf("Error receiving passenger: %v", err)
-		http.Error(w, err.Error(), http.StatusNotFound)
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -426,11 +922,14 @@ func (s *APIServer) handleGetPassengerByID(w http.ResponseWriter, r *http.Reques
 
 // handleCreatePassenger handles requests for creating passenger.
 func (s *APIServer) handleCreatePassenger(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("CreatePassenger called")
-
 	createPassengerReq := new(p.CreatePassengerReq)
-	if err := json.NewDecoder(r.Body).Decode(createPassengerReq); err != nil {
-		utils.ErrorLog.Fatal("Cannot decode passenger data")
+	if err := decodeJSON(r, createPassengerReq); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := createPassengerReq.Validate(); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -442,39 +941,46 @@ func (s *APIServer) handleCreatePassenger(w http.ResponseWriter, r *http.Request
 	)
 
 	if err != nil {
-		utils.ErrorLog.Printf("Error in CreatePassenger: %v", err)
+		s.writeError(w, r, err)
 		return
 	}
 
-	utils.InfoLog.Println("new passenger: ", newPassenger, " created")
-
-	if err := s.store.CreatePassenger(newPassenger); err != nil {
-		utils.ErrorLog.Printf("Error in CreatePassenger: %v", err)
+	if err := s.store.CreatePassenger(r.Context(), newPassenger); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
+	s.logger.InfoContext(r.Context(), "passenger created", "passenger_id", newPassenger.ID)
+
 	WriteJSON(w, http.StatusCreated, "Passenger created")
 }
 
 // handleUpdatePassenger handles requests for updating passenger.
 func (s *APIServer) handleUpdatePassenger(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("UpdatePassenger called")
-
 	vars := mux.Vars(r)
 	passengerID := vars["id"]
 
-	if passengerID == "" {
-		utils.ErrorLog.Printf("Missing required parameters in UpdatePassenger query")
-		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+	if err := required("id", passengerID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizePassenger(r.Context(), passengerID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
 	queryParams := r.URL.Query()
-	utils.InfoLog.Println(queryParams)
+	s.logger.DebugContext(r.Context(), "query parameters", "query", queryParams)
 
 	createPassengerReq := new(p.CreatePassengerReq)
-	if err := json.NewDecoder(r.Body).Decode(createPassengerReq); err != nil {
-		utils.ErrorLog.Fatal("Cannot decode passenger data")
+	if err := decodeJSON(r, createPassengerReq); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := createPassengerReq.Validate(); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
@@ -485,61 +991,70 @@ func (s *APIServer) handleUpdatePassenger(w http.ResponseWriter, r *http.Request
 		createPassengerReq.Password,
 	)
 	if err != nil {
-		utils.ErrorLog.Printf("Error in UpdatePassenger: %v", err)
+		s.writeError(w, r, err)
 		return
 	}
-	utils.InfoLog.Println("Passenger: ", newPassenger, " updated")
-
-	if err := s.store.UpdatePassenger(passengerID, newPassenger); err != nil {
-		utils.ErrorLog.Printf("Error in UpdatePassenger: %v", err)
+	if err := s.store.UpdatePassenger(r.Context(), passengerID, newPassenger); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
+	s.logger.InfoContext(r.Context(), "passenger updated", "passenger_id", passengerID)
+
 	WriteJSON(w, http.StatusOK, "Passenger updated")
 }
 
+// handleSetPassengerRole handles requests for changing role of passenger account.
+func (s *APIServer) handleSetPassengerRole(w http.ResponseWriter, r *http.Request) {
+	passengerID := mux.Vars(r)["id"]
+
+	setRoleReq := new(p.SetRoleReq)
+	if err := decodeJSON(r, setRoleReq); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := setRoleReq.Validate(); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := s.store.SetPassengerRole(r.Context(), passengerID, setRoleReq.Role); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	s.logger.InfoContext(r.Context(), "passenger role changed", "passenger_id", passengerID, "role", setRoleReq.Role)
+
+	WriteJSON(w, http.StatusOK, "Role changed")
+}
+
 // handleDeletePassenger handles requests for deleting passenger.
 func (s *APIServer) handleDeletePassenger(w http.ResponseWriter, r *http.Request) {
-	utils.InfoLog.Println("DeletePassenger called")
-
 	vars := mux.Vars(r)
 	passengerID := vars["id"]
 
-	if passengerID == "" {
-		utils.ErrorLog.Printf("passenger id is empty")
-		http.Error(w, "Missing required parameters", http.StatusBadRequest)
+	if err := required("id", passengerID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := authorizePassenger(r.Context(), passengerID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
-	if err := s.store.DeletePassenger(passengerID); err != nil {
-		utils.ErrorLog.Printf("Error in DeletePassenger: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := s.store.DeletePassenger(r.Context(), passengerID); err != nil {
+		s.writeError(w, r, err)
 		return
 	}
 
 	WriteJSON(w, http.StatusOK, "Passenger deleted")
 }
 
-// // generateID generates unique ID.
-// func generateID() string {
-// 	return uuid.New().String()
-// }
-
 // WriteJSON writes response to JSON
 func WriteJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(v)
 }
-
-func createJWT(passenger *p.Passenger) (string, error) {
-	claims := &jwt.MapClaims{
-		"expiresAt":    15000,
-		"passengerNum": passenger.Number,
-	}
-
-	secret := os.Getenv("JWT_SECRET")
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	return token.SignedString([]byte(secret))
-}
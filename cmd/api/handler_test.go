@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flightticketservice/pkg/apperr"
+	"flightticketservice/pkg/auth"
+	t "flightticketservice/pkg/booking"
+	f "flightticketservice/pkg/flights"
+	"flightticketservice/pkg/pagination"
+	p "flightticketservice/pkg/passenger"
+	"flightticketservice/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer() *APIServer {
+	return newTestServerWithLogger(utils.DiscardLogger())
+}
+
+func newTestServerWithLogger(logger *slog.Logger) *APIServer {
+	registry := prometheus.NewRegistry()
+	passengers, flights, tickets, tokenStore, uow := memoryStores(logger, t.NewMetrics(registry))
+	tokens, err := auth.NewService(auth.Config{Keys: testKeys}, tokenStore, passengers)
+	if err != nil {
+		panic(err)
+	}
+	return NewAPIServer("", "", passengers, flights, tickets, uow, tokens, t.DefaultHoldTTL, DefaultTimeouts(), logger, registry)
+}
+
+// testKeys sign tokens of test servers.
+var testKeys = func() *auth.Keyring {
+	keys, err := auth.NewKeyring(auth.AlgEdDSA)
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}()
+
+// testAdmin is account of requests sent by serve.
+var testAdmin = &p.Passenger{ID: "00000000-0000-4000-8000-000000001000", Role: p.RoleAdmin}
+
+// serve sends request to the router as admin, who may call every route.
+func serve(s *APIServer, method, target, body string) *httptest.ResponseRecorder {
+	return serveAs(s, tokenFor(s, testAdmin), method, target, body)
+}
+
+// serveAs sends request with token, empty token calls the route anonymously.
+func serveAs(s *APIServer, token, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", token)
+	}
+	s.Router().ServeHTTP(w, r)
+	return w
+}
+
+// tokenFor returns access token of the passenger account.
+func tokenFor(s *APIServer, passenger *p.Passenger) string {
+	pair, err := s.tokens.Issue(context.Background(), passenger)
+	if err != nil {
+		panic(err)
+	}
+	return pair.AccessToken
+}
+
+func TestHandleFlightsWithMemoryStores(tt *testing.T) {
+	s := newTestServer()
+
+	departure := time.Now().UTC().Add(48 * time.Hour).Format(time.RFC3339)
+	arrival := time.Now().UTC().Add(52 * time.Hour).Format(time.RFC3339)
+	for _, price := range []string{"300", "200"} {
+		body := `{"airline": "Aeroflot", "origin": "MOW", "destination": "PAR", "departure": "` + departure +
+			`", "arrival": "` + arrival + `", "price": ` + price + `}`
+		w := serve(s, http.MethodPost, "/api/v1/flights/create", body)
+		assert.Equal(tt, http.StatusCreated, w.Code)
+	}
+
+	w := serve(s, http.MethodGet, "/api/v1/flights?limit=1&sort=price", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+
+	var page pagination.Page[*f.Flight]
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&page))
+	assert.Equal(tt, 2, page.Total)
+	assert.Equal(tt, 200.0, page.Items[0].Price)
+	assert.NotEmpty(tt, page.NextCursor)
+
+	w = serve(s, http.MethodGet, "/api/v1/flights?sort=airline", "")
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+
+	w = serve(s, http.MethodGet, "/api/v1/tickets/42", "")
+	assert.Equal(tt, http.StatusNotFound, w.Code)
+}
+
+func TestHandleCreateBookingWithMemoryStores(tt *testing.T) {
+	s := newTestServer()
+
+	departure := time.Now().UTC().Add(48 * time.Hour)
+	flight := f.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	assert.NoError(tt, s.flights.CreateFlight(context.Background(), flight))
+	john := &p.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	assert.NoError(tt, s.store.CreatePassenger(context.Background(), john))
+
+	body := `{"passenger_ids": ["` + john.ID + `"], "segments": [{"flight_id": "` + flight.ID + `", "seats": ["1a"]}]}`
+	w := serve(s, http.MethodPost, "/api/v1/bookings/create", body)
+	assert.Equal(tt, http.StatusCreated, w.Code)
+
+	var record t.BookingRecord
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&record))
+	assert.Equal(tt, "1A", record.Tickets[0].SeatNumber)
+
+	w = serve(s, http.MethodPost, "/api/v1/bookings/create", `{"passenger_ids": ["42"], "segments": [{"flight_id": "`+flight.ID+`"}]}`)
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+	unknown := "123e4567-e89b-12d3-a456-426614174000"
+	w = serve(s, http.MethodPost, "/api/v1/bookings/create", `{"passenger_ids": ["`+unknown+`"], "segments": [{"flight_id": "`+flight.ID+`"}]}`)
+	assert.Equal(tt, http.StatusNotFound, w.Code)
+
+	w = serve(s, http.MethodPost, "/api/v1/bookings/create", `{"passenger_ids": ["`+john.ID+`"], "segments": [{"flight_id": "`+flight.ID+`", "seats": ["1A"]}]}`)
+	assert.Equal(tt, http.StatusConflict, w.Code)
+}
+
+func TestHandleCreateTicketHoldsSeat(tt *testing.T) {
+	s := newTestServer()
+
+	departure := time.Now().UTC().Add(48 * time.Hour)
+	flight := f.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	assert.NoError(tt, s.flights.CreateFlight(context.Background(), flight))
+	john := &p.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	assert.NoError(tt, s.store.CreatePassenger(context.Background(), john))
+
+	body := `{"flight_id": "` + flight.ID + `", "passenger_id": "` + john.ID + `", "departure_time": "` +
+		departure.Format(time.RFC3339) + `", "arrival_time": "` + departure.Add(4*time.Hour).Format(time.RFC3339) + `"}`
+	w := serve(s, http.MethodPost, "/api/v1/tickets/create", body)
+	assert.Equal(tt, http.StatusOK, w.Code)
+
+	w = serve(s, http.MethodGet, "/api/v1/tickets?passenger_id="+john.ID, "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+	var page pagination.Page[*t.Ticket]
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&page))
+	assert.Len(tt, page.Items, 1)
+	assert.Equal(tt, t.StatusHeld, page.Items[0].Status)
+	if assert.NotNil(tt, page.Items[0].HoldExpiresAt, "created ticket is released like any hold") {
+		assert.WithinDuration(tt, time.Now().Add(t.DefaultHoldTTL), *page.Items[0].HoldExpiresAt, time.Minute)
+	}
+}
+
+func TestHandleChangeTicket(tt *testing.T) {
+	s := newTestServer()
+
+	departure := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+	flight := f.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	assert.NoError(tt, s.flights.CreateFlight(context.Background(), flight))
+	later := f.NewFlight("Aeroflot", "MOW", "PAR", departure.Add(24*time.Hour), departure.Add(29*time.Hour), 320)
+	assert.NoError(tt, s.flights.CreateFlight(context.Background(), later))
+	john := &p.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	assert.NoError(tt, s.store.CreatePassenger(context.Background(), john))
+	ticket := t.CreateNewTicket(flight.ID, john.ID, t.StatusBooked, "", "", flight.Departure, flight.Arrival)
+	assert.NoError(tt, s.tickets.CreateTicket(context.Background(), ticket))
+
+	w := serve(s, http.MethodPost, "/api/v1/tickets/"+ticket.ID+"/change", "")
+	assert.Equal(tt, http.StatusBadRequest, w.Code)
+	assert.Equal(tt, []apperr.FieldError{{Field: "flightID", Message: "is required"}}, decodeProblem(tt, w).Errors)
+
+	w = serve(s, http.MethodPost, "/api/v1/tickets/"+ticket.ID+"/change?flightID="+later.ID, "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+
+	changed, err := s.tickets.GetTicketByID(context.Background(), ticket.ID)
+	assert.NoError(tt, err)
+	assert.Equal(tt, later.ID, changed.FlightID)
+	assert.True(tt, later.Departure.Equal(changed.DepartureTime), "ticket departs with its new flight")
+	assert.True(tt, later.Arrival.Equal(changed.ArrivalTime))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultShutdownTimeout is how long Shutdown waits for in-flight requests and workers.
+const DefaultShutdownTimeout = 20 * time.Second
+
+// WriteTimeout is how long the server writes a response, request timeouts must be shorter.
+const WriteTimeout = 15 * time.Second
+
+// worker is a background job that runs until its context is cancelled.
+type worker struct {
+	name   string
+	run    func(ctx context.Context)
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// closer releases a resource after the server and workers are stopped.
+type closer struct {
+	name  string
+	close func() error
+}
+
+// AddWorker registers background job started by Start.
+// Workers are stopped in reverse order, so a worker may depend on the ones added before it.
+func (s *APIServer) AddWorker(name string, run func(ctx context.Context)) {
+	s.workers = append(s.workers, &worker{name: name, run: run})
+}
+
+// OnShutdown registers fn to release a resource, such as database connection, when the server is shut down.
+// Closers run in reverse order after the workers are stopped.
+func (s *APIServer) OnShutdown(name string, fn func() error) {
+	s.closers = append(s.closers, closer{name: name, close: fn})
+}
+
+// Start listens on the server address, then serves requests and runs workers in background.
+// Errors of serving after start are reported by Err.
+func (s *APIServer) Start() error {
+	s.srv = &http.Server{
+		Handler:      s.Router(),
+		Addr:         s.listenAddr + ":" + s.listenPort,
+		WriteTimeout: WriteTimeout,
+		ReadTimeout:  15 * time.Second,
+	}
+
+	listener, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range s.workers {
+		ctx, cancel := context.WithCancel(context.Background())
+		w.cancel, w.done = cancel, make(chan struct{})
+		go func() {
+			defer close(w.done)
+			w.run(ctx)
+		}()
+		s.logger.Info("started worker", "worker", w.name)
+	}
+
+	s.errs = make(chan error, 1)
+	go func() {
+		if err := s.srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			s.errs <- err
+		}
+	}()
+
+	s.logger.Info("server started", "addr", listener.Addr().String())
+	return nil
+}
+
+// Err returns channel that receives error if server stops serving on its own.
+func (s *APIServer) Err() <-chan error {
+	return s.errs
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to finish,
+// then stops workers and runs closers. Connections still active when ctx expires are closed.
+func (s *APIServer) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	if s.srv != nil {
+		if err := s.srv.Shutdown(ctx); err != nil {
+			s.logger.Error("failed to drain connections", "error", err)
+			errs = append(errs, err, s.srv.Close())
+		}
+	}
+
+	for i := len(s.workers) - 1; i >= 0; i-- {
+		w := s.workers[i]
+		if w.cancel == nil {
+			continue
+		}
+
+		w.cancel()
+		select {
+		case <-w.done:
+			s.logger.Info("stopped worker", "worker", w.name)
+		case <-ctx.Done():
+			s.logger.Error("timed out stopping worker", "worker", w.name)
+			errs = append(errs, ctx.Err())
+		}
+	}
+
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		c := s.closers[i]
+		if err := c.close(); err != nil {
+			s.logger.Error("failed to close resource", "resource", c.name, "error", err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"flightticketservice/pkg/auth"
+	t "flightticketservice/pkg/booking"
+	"flightticketservice/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerLifecycle(tt *testing.T) {
+	logger, registry := utils.DiscardLogger(), prometheus.NewRegistry()
+	passengers, flights, tickets, tokenStore, uow := memoryStores(logger, t.NewMetrics(registry))
+	tokens, err := auth.NewService(auth.Config{Keys: testKeys}, tokenStore, passengers)
+	assert.NoError(tt, err)
+	s := NewAPIServer("127.0.0.1", "0", passengers, flights, tickets, uow, tokens, t.DefaultHoldTTL, DefaultTimeouts(), logger, registry)
+
+	stopped := []string{}
+	for _, name := range []string{"notifier", "exporter"} {
+		s.AddWorker(name, func(ctx context.Context) {
+			<-ctx.Done()
+			stopped = append(stopped, name)
+		})
+	}
+	s.OnShutdown("database", func() error {
+		stopped = append(stopped, "database")
+		return nil
+	})
+
+	assert.NoError(tt, s.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(tt, s.Shutdown(ctx))
+
+	assert.Equal(tt, []string{"exporter", "notifier", "database"}, stopped)
+	assert.ErrorIs(tt, s.srv.ListenAndServe(), http.ErrServerClosed)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"flightticketservice/utils"
+)
+
+// routeIDKeys maps resource of a route name to the log attribute of its {id} variable.
+var routeIDKeys = map[string]string{
+	"flights":    "flight_id",
+	"passengers": "passenger_id",
+	"tickets":    "ticket_id",
+}
+
+// withLogContext adds matched route and IDs of requested resources to records
+// logged with request context, so records of one ticket or passenger can be found by its ID.
+func (s *APIServer) withLogContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		name := route.GetName()
+		args := []any{"route_name", name}
+		for key, id := range routeIDs(name, mux.Vars(r)) {
+			args = append(args, key, id)
+		}
+
+		next.ServeHTTP(w, r.WithContext(utils.WithLogAttrs(r.Context(), args...)))
+	})
+}
+
+// routeIDs returns IDs of resources requested by route name and its path variables,
+// by their log and span attribute key.
+func routeIDs(name string, vars map[string]string) map[string]string {
+	ids := map[string]string{}
+	resource, _, _ := strings.Cut(name, ".")
+	if key, ok := routeIDKeys[resource]; ok && vars["id"] != "" {
+		ids[key] = vars["id"]
+	}
+	if locator := vars["locator"]; locator != "" {
+		ids["locator"] = locator
+	}
+	return ids
+}
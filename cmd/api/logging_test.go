@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	t "flightticketservice/pkg/booking"
+	f "flightticketservice/pkg/flights"
+	p "flightticketservice/pkg/passenger"
+	"flightticketservice/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookingEventsLogWithRequestFields(tt *testing.T) {
+	var buf bytes.Buffer
+	logger, err := utils.NewLogger(&buf, utils.LogFormatJSON, "info")
+	assert.NoError(tt, err)
+	s := newTestServerWithLogger(logger)
+
+	ctx := context.Background()
+	departure := time.Now().UTC().Add(48 * time.Hour)
+	flight := f.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	assert.NoError(tt, s.flights.CreateFlight(ctx, flight))
+	john := &p.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	assert.NoError(tt, s.store.CreatePassenger(ctx, john))
+	ticket := t.CreateNewTicket(flight.ID, john.ID, t.StatusBooked, "", "", flight.Departure, flight.Arrival)
+	assert.NoError(tt, s.tickets.CreateTicket(ctx, ticket))
+	buf.Reset()
+
+	w := serve(s, http.MethodPost, "/api/v1/tickets/"+ticket.ID+"/cancel", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+
+	event, _, _ := bytes.Cut(buf.Bytes(), []byte("\n"))
+	var record map[string]any
+	assert.NoError(tt, json.Unmarshal(event, &record))
+	assert.Equal(tt, "ticket cancelled", record["msg"])
+	assert.Equal(tt, "tickets.cancel", record["route_name"])
+	assert.Equal(tt, ticket.ID, record["ticket_id"])
+}
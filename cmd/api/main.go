@@ -1,10 +1,21 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 
+	"flightticketservice/pkg/auth"
 	"flightticketservice/pkg/booking"
 	db "flightticketservice/pkg/database"
 	"flightticketservice/pkg/flights"
@@ -23,40 +34,269 @@ import (
 // @Basepath /
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		utils.InfoLog.Print("No .env file found")
+	envErr := godotenv.Load()
+
+	logger, err := utils.NewLogger(os.Stdout, os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid logging configuration:", err)
+		os.Exit(1)
+	}
+	if envErr != nil {
+		logger.Info("no .env file found")
 	}
 
-	store, err := db.ConnectDB(
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASS"),
-		os.Getenv("DB_NAME"),
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:], logger)
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
+	bookingMetrics := booking.NewMetrics(registry)
 
+	exporter := os.Getenv("TRACING_EXPORTER")
+	closeTracing, err := setupTracing(exporter, os.Getenv("TRACING_FILE"))
 	if err != nil {
-		utils.ErrorLog.Fatal(err)
+		fatal(logger, "invalid tracing configuration", "error", err)
+	}
+	if exporter != "" && exporter != utils.TraceExporterNone {
+		logger.Info("tracing enabled", "exporter", exporter)
 	}
 
-	passengerStore := passenger.NewPostgresStore(store)
-	if err := passengerStore.Init(); err != nil {
-		utils.ErrorLog.Fatal(err)
+	backend := os.Getenv("STORAGE_BACKEND")
+	logger.Info("using storage backend", "backend", backend)
+
+	var (
+		passengerStore passenger.Storage
+		flightsStore   flights.FlightService
+		ticketStore    booking.BookingService
+		tokenStore     auth.Store
+		uow            unitOfWork
+		store          *sql.DB
+	)
+	switch backend {
+	case "", "postgres":
+		if store, err = connectDB(); err == nil {
+			registry.MustRegister(collectors.NewDBStatsCollector(store, os.Getenv("DB_NAME")))
+			passengerStore, flightsStore, ticketStore, tokenStore, uow, err = postgresStores(store, logger, bookingMetrics)
+		}
+	case "memory":
+		passengerStore, flightsStore, ticketStore, tokenStore, uow = memoryStores(logger, bookingMetrics)
+	default:
+		fatal(logger, "unknown STORAGE_BACKEND, use postgres or memory", "backend", backend)
+	}
+
+	if err != nil {
+		fatal(logger, "failed to set up storage", "error", err)
 	}
 
-	flightsStore := flights.NewFlightsStore(store)
-	if err := flightsStore.Init(); err != nil {
-		utils.ErrorLog.Fatal(err)
+	if email := os.Getenv("ADMIN_EMAIL"); email != "" {
+		admin, err := ensureAdmin(context.Background(), passengerStore, email, os.Getenv("ADMIN_PASSWORD"))
+		if err != nil {
+			fatal(logger, "failed to set up admin account", "error", err)
+		}
+		logger.Info("admin account is ready", "passenger_id", admin.ID)
 	}
 
-	ticketStore := booking.NewBookingStore(store)
-	if err := ticketStore.Init(); err != nil {
-		utils.ErrorLog.Fatal(err)
+	tokenConfig := auth.Config{
+		Issuer:   os.Getenv("JWT_ISSUER"),
+		Audience: os.Getenv("JWT_AUDIENCE"),
+	}
+	if ttl := os.Getenv("ACCESS_TOKEN_TTL"); ttl != "" {
+		if tokenConfig.AccessTTL, err = time.ParseDuration(ttl); err != nil || tokenConfig.AccessTTL <= 0 {
+			fatal(logger, "invalid ACCESS_TOKEN_TTL", "value", ttl)
+		}
+	}
+	if ttl := os.Getenv("REFRESH_TOKEN_TTL"); ttl != "" {
+		if tokenConfig.RefreshTTL, err = time.ParseDuration(ttl); err != nil || tokenConfig.RefreshTTL <= 0 {
+			fatal(logger, "invalid REFRESH_TOKEN_TTL", "value", ttl)
+		}
+	}
+	var rotator *auth.KeyRotator
+	tokenConfig.Keys, rotator, err = loadKeys(cmp.Or(tokenConfig.AccessTTL, auth.DefaultAccessTTL), logger)
+	if err != nil {
+		fatal(logger, "invalid signing keys, set JWT_KEYS_DIR or JWT_SECRET", "error", err)
+	}
+	tokens, err := auth.NewService(tokenConfig, tokenStore, passengerStore)
+	if err != nil {
+		fatal(logger, "invalid token configuration", "error", err)
 	}
 
 	host := os.Getenv("HOST")
 	port := os.Getenv("PORT")
-	utils.InfoLog.Printf("loaded env {'host': %s, 'port': %s}", host, port)
+	logger.Info("loaded env", "host", host, "port", port)
+
+	holdTTL := booking.DefaultHoldTTL
+	if ttl := os.Getenv("HOLD_TTL"); ttl != "" {
+		if holdTTL, err = time.ParseDuration(ttl); err != nil || holdTTL <= 0 {
+			fatal(logger, "invalid HOLD_TTL", "value", ttl)
+		}
+	}
+
+	timeouts := DefaultTimeouts()
+	if timeout := os.Getenv("REQUEST_TIMEOUT"); timeout != "" {
+		timeouts.Default, err = time.ParseDuration(timeout)
+		if err != nil || timeouts.Default <= 0 || timeouts.Default >= WriteTimeout {
+			fatal(logger, "invalid REQUEST_TIMEOUT", "value", timeout)
+		}
+	}
+	if err := timeouts.ParseRouteTimeouts(os.Getenv("ROUTE_TIMEOUTS")); err != nil {
+		fatal(logger, "invalid ROUTE_TIMEOUTS", "error", err)
+	}
+
+	server := NewAPIServer(host, port, passengerStore, flightsStore, ticketStore, uow, tokens, holdTTL, timeouts, logger, registry)
+
+	if rotator != nil {
+		server.AddWorker("key rotator", rotator.Run)
+	}
+
+	router := server.Router()
+	for name := range timeouts.Routes {
+		if router.Get(name) == nil {
+			fatal(logger, "invalid ROUTE_TIMEOUTS: unknown route", "route", name)
+		}
+	}
+
+	// closers run in reverse order, spans of the last requests are flushed after the database is closed
+	server.OnShutdown("tracing", closeTracing)
+	if store != nil {
+		server.OnShutdown("database", store.Close)
+	}
+
+	shutdownTimeout := DefaultShutdownTimeout
+	if timeout := os.Getenv("SHUTDOWN_TIMEOUT"); timeout != "" {
+		shutdownTimeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			fatal(logger, "invalid SHUTDOWN_TIMEOUT", "error", err)
+		}
+	}
+
+	if err := server.Start(); err != nil {
+		fatal(logger, "server failed to start", "error", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("shutting down")
+	case serveErr = <-server.Err():
+		logger.Error("server failed", "error", serveErr)
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		fatal(logger, "shutdown failed", "error", err)
+	}
+	logger.Info("server stopped")
+
+	if serveErr != nil {
+		os.Exit(1)
+	}
+}
+
+// loadKeys returns keys that sign access tokens: keys of JWT_KEYS_DIR rotated by the returned rotator,
+// or HMAC key JWT_SECRET that only this service can verify tokens with.
+func loadKeys(accessTTL time.Duration, logger *slog.Logger) (*auth.Keyring, *auth.KeyRotator, error) {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		logger.Warn("signing tokens with JWT_SECRET, other services cannot verify them, set JWT_KEYS_DIR")
+		keys, err := auth.NewSecretKeyring([]byte(os.Getenv("JWT_SECRET")))
+		return keys, nil, err
+	}
+
+	var period time.Duration
+	overlap := accessTTL
+	for name, value := range map[string]*time.Duration{"JWT_KEY_ROTATION": &period, "JWT_KEY_OVERLAP": &overlap} {
+		if env := os.Getenv(name); env != "" {
+			parsed, err := time.ParseDuration(env)
+			if err != nil || parsed < 0 {
+				return nil, nil, fmt.Errorf("invalid %s %q", name, env)
+			}
+			*value = parsed
+		}
+	}
+	if period == 0 {
+		logger.Warn("signing keys are not rotated, set JWT_KEY_ROTATION to generate keys periodically")
+	}
+	if overlap < accessTTL {
+		return nil, nil, fmt.Errorf("JWT_KEY_OVERLAP %s is shorter than access token TTL %s", overlap, accessTTL)
+	}
+
+	keys, err := auth.LoadKeyring(dir, cmp.Or(os.Getenv("JWT_SIGNING_ALG"), auth.AlgRS256))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the first check runs before the server starts, it generates the first key of a directory without private keys
+	rotator := auth.NewKeyRotator(keys, period, overlap, keyCheckInterval, logger)
+	if err := rotator.Rotate(context.Background()); err != nil {
+		return nil, nil, err
+	}
+	return keys, rotator, nil
+}
+
+// fatal logs error and exits, it is used when the server cannot be configured or started.
+func fatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// connectDB connects to the database configured by DB_* variables.
+func connectDB() (*sql.DB, error) {
+	return db.ConnectDB(
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASS"),
+		os.Getenv("DB_NAME"),
+	)
+}
+
+// postgresStores applies pending migrations and creates stores on the database.
+func postgresStores(
+	store *sql.DB,
+	logger *slog.Logger,
+	metrics *booking.Metrics,
+) (passenger.Storage, flights.FlightService, booking.BookingService, auth.Store, unitOfWork, error) {
+	migrator, err := db.NewMigrator(store, logger)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	version, err := migrator.Up()
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+	logger.Info("database schema is up to date", "version", version)
+
+	return passenger.NewPostgresStore(store),
+		flights.NewFlightsStore(store),
+		booking.NewBookingStore(store, logger, metrics),
+		auth.NewPostgresStore(store),
+		&txUnitOfWork{db: store, logger: logger, metrics: metrics},
+		nil
+}
+
+// memoryStores creates in-memory stores, data is lost when the server stops.
+func memoryStores(
+	logger *slog.Logger,
+	metrics *booking.Metrics,
+) (passenger.Storage, flights.FlightService, booking.BookingService, auth.Store, unitOfWork) {
+	// writes of the stores wait for units of work like writes of rows locked by a transaction
+	lock := db.NewWriteLock()
+	passengerStore := passenger.NewMemoryStore().WithWriteLock(lock)
+	flightsStore := flights.NewMemoryStore().WithWriteLock(lock)
+	ticketStore := booking.NewMemoryStore(flightsStore, passengerStore, logger, metrics).WithWriteLock(lock)
+	flightsStore.SetOccupancy(ticketStore.Occupancy)
 
-	server := NewAPIServer(host, port, passengerStore, flightsStore, ticketStore)
-	server.Run()
+	uow := &memoryUnitOfWork{lock: lock, passengers: passengerStore, flights: flightsStore, tickets: ticketStore}
+	return passengerStore, flightsStore, ticketStore, auth.NewMemoryStore(), uow
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute labels requests that match no route, so unknown paths don't create new series.
+const unmatchedRoute = "unmatched"
+
+// newRequestDuration creates histogram of request latency by route name and status and registers it with reg.
+func newRequestDuration(reg prometheus.Registerer) *prometheus.HistogramVec {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "flightticket",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by route name and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "status"})
+	reg.MustRegister(duration)
+
+	return duration
+}
+
+// withMetrics observes latency and status of every request.
+func (s *APIServer) withMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw, ok := w.(*statusWriter)
+		if !ok {
+			sw = &statusWriter{ResponseWriter: w}
+		}
+
+		start := time.Now()
+		next.ServeHTTP(sw, r)
+
+		route := unmatchedRoute
+		if current := mux.CurrentRoute(r); current != nil {
+			route = current.GetName()
+		}
+		s.requestDuration.WithLabelValues(route, strconv.Itoa(sw.Status())).Observe(time.Since(start).Seconds())
+	})
+}
+
+// metricsHandler serves metrics of the registry in Prometheus text format.
+func (s *APIServer) metricsHandler() http.Handler {
+	return promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{})
+}
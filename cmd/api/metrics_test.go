@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	f "flightticketservice/pkg/flights"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsEndpoint(tt *testing.T) {
+	s := newTestServer()
+
+	departure := time.Now().UTC().Add(48 * time.Hour)
+	flight := f.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	assert.NoError(tt, s.flights.CreateFlight(context.Background(), flight))
+
+	serve(s, http.MethodGet, "/api/v1/tickets/42", "")
+	serve(s, http.MethodGet, "/api/v1/unknown", "")
+
+	w := serve(s, http.MethodGet, "/metrics", "")
+	assert.Equal(tt, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(tt, body, `flightticket_http_request_duration_seconds_count{route="tickets.get",status="404"} 1`)
+	assert.Contains(tt, body, `flightticket_http_request_duration_seconds_count{route="unmatched",status="404"} 1`)
+	assert.Contains(tt, body, `flightticket_seats_remaining{flight_id="`+flight.ID+`"} 174`)
+}
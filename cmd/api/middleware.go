@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"flightticketservice/utils"
+)
+
+// requestIDHeader carries request ID from the client or proxy and back in the response.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits request ID accepted from the client.
+const maxRequestIDLength = 64
+
+// Use registers middleware run around every request, including ones that match no route.
+// Middleware run in order of registration, the first one registered is the outermost.
+func (s *APIServer) Use(mw ...mux.MiddlewareFunc) {
+	s.middlewares = append(s.middlewares, mw...)
+}
+
+// wrap applies registered middleware to handler that is not run by a route, like not found handler.
+func (s *APIServer) wrap(handler http.Handler) http.Handler {
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+	return handler
+}
+
+// withRequestID takes request ID from X-Request-ID header or generates a new one,
+// returns it in the response and adds it to records logged with request context.
+func (s *APIServer) withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(utils.WithLogAttrs(r.Context(), "request_id", id)))
+	})
+}
+
+// validRequestID reports if request ID from the client is short and has only letters, digits, '-', '_' and '.'.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates random 32 hex digits request ID.
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// withAccessLog logs method, route template, status, latency and size of every response.
+func (s *APIServer) withAccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		route := ""
+		if current := mux.CurrentRoute(r); current != nil {
+			route, _ = current.GetPathTemplate()
+		}
+
+		level := slog.LevelInfo
+		if sw.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		s.logger.Log(r.Context(), level, "request",
+			"method", r.Method,
+			"route", route,
+			"path", r.URL.Path,
+			"status", sw.Status(),
+			"duration", time.Since(start),
+			"bytes", sw.bytes,
+		)
+	})
+}
+
+// withRecovery turns panic of a handler into 500 response with JSON Problem,
+// so the client gets an answer instead of a dropped connection.
+func (s *APIServer) withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw, ok := w.(*statusWriter)
+		if !ok {
+			sw = &statusWriter{ResponseWriter: w}
+		}
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			s.logger.ErrorContext(r.Context(), "handler panicked",
+				"error", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()),
+			)
+			if sw.status != 0 {
+				// response is already started, the client sees it cut off
+				return
+			}
+			writeProblem(sw, newProblem(sw, r, http.StatusInternalServerError, codeInternal, "internal server error"))
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// statusWriter records status and size of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Status returns response status, 200 if handler wrote nothing.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flightticketservice/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareStack(tt *testing.T) {
+	var buf bytes.Buffer
+	logger, err := utils.NewLogger(&buf, utils.LogFormatJSON, "info")
+	assert.NoError(tt, err)
+	s := newTestServerWithLogger(logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tickets/42", nil)
+	req.Header.Set(requestIDHeader, "req-42")
+	req.Header.Set("Authorization", tokenFor(s, testAdmin))
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, req)
+	assert.Equal(tt, http.StatusNotFound, w.Code)
+	assert.Equal(tt, "req-42", w.Header().Get(requestIDHeader))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var record map[string]any
+	assert.NoError(tt, json.Unmarshal(lines[len(lines)-1], &record))
+	assert.Equal(tt, "request", record["msg"])
+	assert.Equal(tt, "/api/v1/tickets/{id}", record["route"])
+	assert.Equal(tt, 404.0, record["status"])
+	assert.Equal(tt, "req-42", record["request_id"])
+	assert.Equal(tt, "42", record["ticket_id"])
+
+	w = serve(s, http.MethodGet, "/api/v1/unknown", "")
+	assert.Equal(tt, http.StatusNotFound, w.Code)
+	assert.Len(tt, w.Header().Get(requestIDHeader), 32)
+
+	buf.Reset()
+	handler := s.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(tt, http.StatusInternalServerError, w.Code)
+
+	var problem Problem
+	assert.NoError(tt, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(tt, codeInternal, problem.Code)
+	assert.Contains(tt, buf.String(), `"msg":"handler panicked"`)
+	assert.Contains(tt, buf.String(), `"status":500`)
+}
+
+func TestValidRequestID(tt *testing.T) {
+	assert.True(tt, validRequestID("6f1c2a.req_1-b"))
+	assert.False(tt, validRequestID(""))
+	assert.False(tt, validRequestID("id with spaces"))
+	assert.False(tt, validRequestID(strings.Repeat("a", maxRequestIDLength+1)))
+}
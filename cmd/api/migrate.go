@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log/slog"
+	"strconv"
+
+	db "flightticketservice/pkg/database"
+)
+
+const migrateUsage = "usage: api migrate [up | down [steps] | version]"
+
+// runMigrate handles the migrate subcommand, down reverts one migration unless steps are given.
+func runMigrate(args []string, logger *slog.Logger) {
+	store, err := connectDB()
+	if err != nil {
+		fatal(logger, "failed to connect to database", "error", err)
+	}
+	defer store.Close()
+
+	migrator, err := db.NewMigrator(store, logger)
+	if err != nil {
+		fatal(logger, "failed to load migrations", "error", err)
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	var version int
+	switch {
+	case command == "up" && len(args) <= 1:
+		version, err = migrator.Up()
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				fatal(logger, "invalid number of steps", "steps", args[1])
+			}
+		}
+		version, err = migrator.Down(steps)
+	case command == "version" && len(args) <= 1:
+		version, err = migrator.Version()
+	default:
+		fatal(logger, migrateUsage)
+	}
+
+	if err != nil {
+		fatal(logger, "migration failed", "error", err)
+	}
+	logger.Info("database schema version", "version", version, "latest", migrator.Latest())
+}
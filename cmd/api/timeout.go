@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultRequestTimeout bounds store operations of routes without their own timeout,
+// it is below WriteTimeout of the server so the client gets an error response.
+const DefaultRequestTimeout = 5 * time.Second
+
+// defaultRouteTimeouts gives searches that run many queries more time.
+var defaultRouteTimeouts = map[string]time.Duration{
+	"flights.search":      12 * time.Second,
+	"flights.itineraries": 12 * time.Second,
+}
+
+// Timeouts holds deadlines of request contexts by route name.
+type Timeouts struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// DefaultTimeouts returns DefaultRequestTimeout with longer timeouts of searches.
+func DefaultTimeouts() Timeouts {
+	routes := make(map[string]time.Duration, len(defaultRouteTimeouts))
+	for name, timeout := range defaultRouteTimeouts {
+		routes[name] = timeout
+	}
+	return Timeouts{Default: DefaultRequestTimeout, Routes: routes}
+}
+
+// For returns timeout of the route.
+func (tm Timeouts) For(route string) time.Duration {
+	if timeout, ok := tm.Routes[route]; ok {
+		return timeout
+	}
+	return tm.Default
+}
+
+// ParseRouteTimeouts overrides timeouts with a comma separated list like "flights.search=14s,bookings.create=8s".
+// Timeouts must be shorter than WriteTimeout, otherwise the client gets no response when they expire.
+func (tm *Timeouts) ParseRouteTimeouts(value string) error {
+	if tm.Routes == nil {
+		tm.Routes = map[string]time.Duration{}
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		route, duration, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("route timeout %q: expected route=duration", item)
+		}
+
+		timeout, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("route timeout %q: invalid duration", item)
+		}
+		if timeout >= WriteTimeout {
+			return fmt.Errorf("route timeout %q: must be shorter than write timeout %s", item, WriteTimeout)
+		}
+		tm.Routes[strings.TrimSpace(route)] = timeout
+	}
+	return nil
+}
+
+// withTimeout cancels request context when timeout of the matched route expires,
+// so queries of a slow or abandoned request are cancelled.
+func (s *APIServer) withTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := ""
+		if route := mux.CurrentRoute(r); route != nil {
+			name = route.GetName()
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), s.timeouts.For(name))
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRouteTimeouts(tt *testing.T) {
+	timeouts := DefaultTimeouts()
+	assert.NoError(tt, timeouts.ParseRouteTimeouts("bookings.create=8s, flights.search=14s,"))
+	assert.Equal(tt, 8*time.Second, timeouts.For("bookings.create"))
+	assert.Equal(tt, 14*time.Second, timeouts.For("flights.search"))
+	assert.Equal(tt, 12*time.Second, timeouts.For("flights.itineraries"))
+	assert.Equal(tt, DefaultRequestTimeout, timeouts.For("flights.list"))
+
+	assert.Error(tt, timeouts.ParseRouteTimeouts("bookings.create"))
+	assert.Error(tt, timeouts.ParseRouteTimeouts("bookings.create=soon"))
+	assert.Error(tt, timeouts.ParseRouteTimeouts("bookings.create=-1s"))
+	assert.Error(tt, timeouts.ParseRouteTimeouts("flights.search=15s"))
+	assert.Error(tt, timeouts.ParseRouteTimeouts("flights.search=20s"))
+}
+
+func TestWithTimeout(tt *testing.T) {
+	s := &APIServer{timeouts: Timeouts{Default: time.Minute, Routes: map[string]time.Duration{"slow": time.Hour}}}
+
+	deadlines := map[string]time.Duration{}
+	r := mux.NewRouter()
+	r.Use(s.withTimeout)
+	for _, name := range []string{"slow", "fast"} {
+		r.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok := r.Context().Deadline()
+			assert.True(tt, ok)
+			deadlines[mux.CurrentRoute(r).GetName()] = time.Until(deadline)
+		}).Name(name)
+	}
+
+	for _, name := range []string{"slow", "fast"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/"+name, nil))
+	}
+
+	assert.InDelta(tt, time.Hour, deadlines["slow"], float64(time.Second))
+	assert.InDelta(tt, time.Minute, deadlines["fast"], float64(time.Second))
+}
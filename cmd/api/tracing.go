@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"flightticketservice/utils"
+)
+
+// tracer starts server spans of requests, spans of stores and SQL statements are their children.
+var tracer = otel.Tracer("flightticketservice/cmd/api")
+
+// tracingShutdownTimeout bounds export of spans still pending when the server stops.
+const tracingShutdownTimeout = 5 * time.Second
+
+// setupTracing installs tracer provider exporting spans with exporter and W3C trace context propagation.
+// It returns closer that flushes pending spans, to run when the server stops.
+func setupTracing(exporter, path string) (func() error, error) {
+	provider, closeOutput, err := utils.NewTracerProvider(context.Background(), exporter, path)
+	if err != nil {
+		return nil, err
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if provider == nil {
+		return closeOutput, nil
+	}
+	otel.SetTracerProvider(provider)
+
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		return errors.Join(provider.Shutdown(ctx), closeOutput())
+	}, nil
+}
+
+// withTracing starts span of the request, continuing trace of the caller from traceparent header,
+// and adds trace ID to records logged with request context.
+func (s *APIServer) withTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		name := r.Method
+		attrs := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLPath(r.URL.Path),
+		}
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				name += " " + template
+				attrs = append(attrs, semconv.HTTPRoute(template))
+			}
+			for key, id := range routeIDs(route.GetName(), mux.Vars(r)) {
+				attrs = append(attrs, attribute.String(key, id))
+			}
+		}
+
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			ctx = utils.WithLogAttrs(ctx, "trace_id", sc.TraceID().String())
+		}
+
+		sw, ok := w.(*statusWriter)
+		if !ok {
+			sw = &statusWriter{ResponseWriter: w}
+		}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(sw.Status()))
+		if sw.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.Status()))
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flightticketservice/utils"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddleware(tt *testing.T) {
+	spans := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var buf bytes.Buffer
+	logger, err := utils.NewLogger(&buf, utils.LogFormatJSON, "info")
+	assert.NoError(tt, err)
+	s := newTestServerWithLogger(logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tickets/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("Authorization", tokenFor(s, testAdmin))
+	w := httptest.NewRecorder()
+	s.Router().ServeHTTP(w, req)
+	assert.Equal(tt, http.StatusNotFound, w.Code)
+
+	ended := spans.GetSpans()
+	assert.Len(tt, ended, 1)
+	span := ended[0]
+	assert.Equal(tt, "GET /api/v1/tickets/{id}", span.Name)
+	assert.Equal(tt, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(tt, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(tt, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Contains(tt, span.Attributes, attribute.String("ticket_id", "42"))
+	assert.Contains(tt, span.Attributes, attribute.Int("http.response.status_code", http.StatusNotFound))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var record map[string]any
+	assert.NoError(tt, json.Unmarshal(lines[len(lines)-1], &record))
+	assert.Equal(tt, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	t "flightticketservice/pkg/booking"
+	"flightticketservice/pkg/database"
+	f "flightticketservice/pkg/flights"
+	p "flightticketservice/pkg/passenger"
+)
+
+// stores groups storages a unit of work runs on.
+type stores struct {
+	passengers p.Storage
+	flights    f.FlightService
+	tickets    t.BookingService
+}
+
+// seatNumber returns canonical seat number if seat exists on the flight.
+func (st stores) seatNumber(ctx context.Context, flightID, seatNumber string) (string, error) {
+	if seatNumber == "" {
+		return "", nil
+	}
+
+	flight, err := st.flights.GetFlightByID(ctx, flightID)
+	if err != nil {
+		return "", err
+	}
+
+	return canonicalSeat(flight, seatNumber)
+}
+
+// unitOfWork runs handler steps that read and change several stores as one operation.
+// fn may be run several times, so it must not write the response.
+type unitOfWork interface {
+	Do(ctx context.Context, fn func(st stores) error) error
+}
+
+// txUnitOfWork runs fn on postgres stores bound to one serializable transaction.
+type txUnitOfWork struct {
+	db      *sql.DB
+	logger  *slog.Logger
+	metrics *t.Metrics
+}
+
+// Booking events of an attempt are reported after the transaction ends, those of retried attempts are dropped.
+func (u *txUnitOfWork) Do(ctx context.Context, fn func(st stores) error) error {
+	var events *t.Events
+	err := database.RunInTx(ctx, u.db, func(tx *sql.Tx) error {
+		events = &t.Events{}
+		return fn(stores{
+			passengers: p.NewPostgresStore(tx),
+			flights:    f.NewFlightsStore(tx),
+			tickets:    t.NewBookingStore(tx, u.logger, u.metrics).WithEvents(events),
+		})
+	})
+	events.Flush(err == nil)
+	return err
+}
+
+// memoryUnitOfWork runs units of work on in-memory stores one at a time. It holds the write lock
+// of the stores while fn runs, so writes of other callers wait for it, and restores the stores
+// to their state before fn if it fails or panics, like a rolled back transaction.
+// Booking events are reported when fn returns.
+type memoryUnitOfWork struct {
+	lock       database.WriteLock
+	passengers *p.MemoryStore
+	flights    *f.MemoryStore
+	tickets    *t.MemoryStore
+}
+
+func (u *memoryUnitOfWork) Do(ctx context.Context, fn func(st stores) error) (err error) {
+	held, unlock := u.lock.Hold()
+	defer unlock()
+
+	restores := []func(){u.passengers.Snapshot(), u.flights.Snapshot(), u.tickets.Snapshot()}
+	events := &t.Events{}
+	committed := false
+	defer func() {
+		if !committed {
+			for _, restore := range restores {
+				restore()
+			}
+		}
+		events.Flush(committed)
+	}()
+
+	err = fn(stores{
+		passengers: u.passengers.WithWriteLock(held),
+		flights:    u.flights.WithWriteLock(held),
+		tickets:    u.tickets.WithWriteLock(held).WithEvents(events),
+	})
+	committed = err == nil
+	return err
+}
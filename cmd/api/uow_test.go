@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	t "flightticketservice/pkg/booking"
+	f "flightticketservice/pkg/flights"
+	p "flightticketservice/pkg/passenger"
+	"flightticketservice/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryUnitOfWorkRollsBack(tt *testing.T) {
+	ctx := context.Background()
+	passengers, flights, tickets, _, uow := memoryStores(utils.DiscardLogger(), t.NewMetrics(prometheus.NewRegistry()))
+
+	departure := time.Now().UTC().Add(48 * time.Hour)
+	flight := f.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	assert.NoError(tt, flights.CreateFlight(ctx, flight))
+	john := &p.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	assert.NoError(tt, passengers.CreatePassenger(ctx, john))
+
+	// changes of every store made before fn fails are undone
+	errFailed := errors.New("failed")
+	jane := &p.Passenger{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	err := uow.Do(ctx, func(st stores) error {
+		assert.NoError(tt, st.passengers.CreatePassenger(ctx, jane))
+		assert.NoError(tt, st.passengers.UpdatePassenger(ctx, john.ID, &p.Passenger{FirstName: "Johnny", LastName: "Doe", Email: "john@example.com"}))
+		ticket := t.CreateNewTicket(flight.ID, john.ID, t.StatusBooked, "1A", "", flight.Departure, flight.Arrival)
+		assert.NoError(tt, st.tickets.CreateTicket(ctx, ticket))
+		assert.NoError(tt, st.flights.DeleteFlight(ctx, flight.ID))
+		return errFailed
+	})
+	assert.ErrorIs(tt, err, errFailed)
+
+	_, err = passengers.GetPassengerByID(ctx, jane.ID)
+	assert.ErrorIs(tt, err, p.ErrPassengerNotFound)
+	found, err := passengers.GetPassengerByID(ctx, john.ID)
+	assert.NoError(tt, err)
+	assert.Equal(tt, "John", found.FirstName)
+	_, err = flights.GetFlightByID(ctx, flight.ID)
+	assert.NoError(tt, err)
+	seats, err := tickets.GetTakenSeats(ctx, flight.ID)
+	assert.NoError(tt, err)
+	assert.Empty(tt, seats)
+
+	assert.Panics(tt, func() {
+		_ = uow.Do(ctx, func(st stores) error {
+			assert.NoError(tt, st.passengers.CreatePassenger(ctx, jane))
+			panic("handler bug")
+		})
+	})
+	_, err = passengers.GetPassengerByEmail(ctx, jane.Email)
+	assert.ErrorIs(tt, err, p.ErrPassengerNotFound)
+
+	// a successful unit keeps its changes
+	assert.NoError(tt, uow.Do(ctx, func(st stores) error {
+		return st.passengers.CreatePassenger(ctx, jane)
+	}))
+	_, err = passengers.GetPassengerByEmail(ctx, jane.Email)
+	assert.NoError(tt, err)
+}
+
+func TestMemoryUnitOfWorkKeepsConcurrentWrites(tt *testing.T) {
+	ctx := context.Background()
+	passengers, flights, _, _, uow := memoryStores(utils.DiscardLogger(), t.NewMetrics(prometheus.NewRegistry()))
+
+	departure := time.Now().UTC().Add(48 * time.Hour)
+	rolledBack := f.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	john := &p.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	written := make(chan error)
+
+	errFailed := errors.New("failed")
+	err := uow.Do(ctx, func(st stores) error {
+		assert.NoError(tt, st.flights.CreateFlight(ctx, rolledBack))
+		// another request writes while the unit of work runs, it waits for the unit of work to end
+		go func() {
+			written <- passengers.CreatePassenger(ctx, john)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		return errFailed
+	})
+	assert.ErrorIs(tt, err, errFailed)
+	assert.NoError(tt, <-written)
+
+	_, err = passengers.GetPassengerByID(ctx, john.ID)
+	assert.NoError(tt, err, "write of another request survives the rollback")
+	_, err = flights.GetFlightByID(ctx, rolledBack.ID)
+	assert.ErrorIs(tt, err, f.ErrFlightNotFound)
+
+	// IDs of rolled back flights are not given out again
+	flight := f.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	assert.NoError(tt, flights.CreateFlight(ctx, flight))
+	assert.NotEqual(tt, rolledBack.ID, flight.ID)
+}
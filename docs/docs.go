@@ -15,9 +15,182 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/bookings/create": {
+            "post": {
+                "description": "Books several passengers on several flight segments under one 6-character locator.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookings"
+                ],
+                "summary": "Create booking record",
+                "parameters": [
+                    {
+                        "description": "Passengers and segments",
+                        "name": "booking",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/booking.CreateBookingReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/booking.BookingRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid booking data"
+                    },
+                    "404": {
+                        "description": "Passenger or flight not found"
+                    },
+                    "409": {
+                        "description": "Flight is sold out or seat is taken"
+                    }
+                }
+            }
+        },
+        "/api/v1/bookings/{locator}": {
+            "get": {
+                "description": "Returns booking record by locator and last name of one of its passengers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookings"
+                ],
+                "summary": "Get booking record",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Booking locator",
+                        "name": "locator",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last name of a passenger in the booking",
+                        "name": "last_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/booking.BookingRecord"
+                        }
+                    },
+                    "404": {
+                        "description": "Booking not found"
+                    }
+                }
+            }
+        },
+        "/api/v1/bookings/{locator}/cancel": {
+            "post": {
+                "description": "Cancels every ticket of the booking. Fails without changes if any ticket cannot be cancelled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bookings"
+                ],
+                "summary": "Cancel booking record",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Booking locator",
+                        "name": "locator",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last name of a passenger in the booking",
+                        "name": "last_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Booking cancelled"
+                    },
+                    "404": {
+                        "description": "Booking not found"
+                    },
+                    "409": {
+                        "description": "Ticket cannot be cancelled in its current status"
+                    }
+                }
+            }
+        },
+        "/api/v1/checkin": {
+            "post": {
+                "description": "Checks ticket in for the flight and assigns or confirms a seat. Available from 24 to 1 hours before departure.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "booking"
+                ],
+                "summary": "Online check-in",
+                "parameters": [
+                    {
+                        "description": "Check-in data",
+                        "name": "checkin",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/booking.CheckInReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/booking.Ticket"
+                        }
+                    },
+                    "400": {
+                        "description": "Seat number is required"
+                    },
+                    "404": {
+                        "description": "Ticket not found"
+                    },
+                    "409": {
+                        "description": "Ticket is cancelled, already checked in or cannot be checked in"
+                    },
+                    "422": {
+                        "description": "Check-in is not open yet or already closed"
+                    }
+                }
+            }
+        },
         "/api/v1/flights": {
             "get": {
-                "description": "get flights",
+                "description": "get flights page by page",
                 "consumes": [
                     "application/json"
                 ],
@@ -28,15 +201,63 @@ const docTemplate = `{
                     "flights"
                 ],
                 "summary": "Get list of flights",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size, 50 by default, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "departure",
+                            "-departure",
+                            "arrival",
+                            "-arrival",
+                            "price",
+                            "-price"
+                        ],
+                        "type": "string",
+                        "description": "Sort key, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Origin of the flight",
+                        "name": "origin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Destination of the flight",
+                        "name": "destination",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Airline of the flight",
+                        "name": "airline",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/flights.Flight"
-                            }
+                            "$ref": "#/definitions/pagination.Page-flights_Flight"
                         }
+                    },
+                    "400": {
+                        "description": "Invalid pagination parameters or unknown filter"
                     }
                 }
             }
@@ -75,9 +296,49 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/flights/itineraries": {
+            "post": {
+                "description": "Finds priced combinations of flights for round trip or multi-city legs, cheapest first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "flights"
+                ],
+                "summary": "Search itineraries",
+                "parameters": [
+                    {
+                        "description": "Legs with origin, destination and date",
+                        "name": "itinerary",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/flights.ItineraryReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/flights.Itinerary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid legs"
+                    }
+                }
+            }
+        },
         "/api/v1/flights/search": {
             "get": {
-                "description": "Retrieves a list of flights filtered by the provided search parameters.",
+                "description": "Retrieves a list of flights filtered by the provided search parameters.\nWith max_stops it returns connecting itineraries from origin to destination instead, departing on departure_date.",
                 "consumes": [
                     "application/json"
                 ],
@@ -112,11 +373,89 @@ const docTemplate = `{
                         "description": "Arrival date and time",
                         "name": "arrival",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Airline of the flight",
+                        "name": "airline",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Departure date, YYYY-MM-DD",
+                        "name": "departure_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Also match departures this many days before and after departure_date, up to 7",
+                        "name": "flex_days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest departure time of day, HH:MM",
+                        "name": "departure_time_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest departure time of day, HH:MM",
+                        "name": "departure_time_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum price",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum price",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of stops, from 0 to 2",
+                        "name": "max_stops",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Minimum connection time, e.g. 45m",
+                        "name": "min_connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Maximum layover, e.g. 12h",
+                        "name": "max_layover",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "departure",
+                            "arrival",
+                            "price",
+                            "duration"
+                        ],
+                        "type": "string",
+                        "description": "Sort flights by departure, arrival, price or duration; itineraries by duration or price",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of itineraries",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Flights, or flights.Itinerary list when max_stops is set",
                         "schema": {
                             "type": "array",
                             "items": {
@@ -124,6 +463,9 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "400": {
+                        "description": "Invalid search parameters"
+                    },
                     "404": {
                         "description": "No flights found matching the search criteria"
                     }
@@ -193,6 +535,47 @@ const docTemplate = `{
                     },
                     "404": {
                         "description": "Passenger not found"
+                    },
+                    "409": {
+                        "description": "Flight has tickets"
+                    }
+                }
+            }
+        },
+        "/api/v1/flights/{id}/seats": {
+            "get": {
+                "description": "Returns seat map of the flight with availability of every seat.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "flights"
+                ],
+                "summary": "Get flight seats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unique identifier of the flight",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/flights.SeatAvailability"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Flight not found"
                     }
                 }
             }
@@ -240,7 +623,7 @@ const docTemplate = `{
         },
         "/api/v1/passengers": {
             "get": {
-                "description": "get passengers",
+                "description": "get passengers page by page",
                 "consumes": [
                     "application/json"
                 ],
@@ -251,15 +634,57 @@ const docTemplate = `{
                     "passengers"
                 ],
                 "summary": "Get list of passengers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size, 50 by default, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "created_at",
+                            "-created_at",
+                            "last_name",
+                            "-last_name",
+                            "email",
+                            "-email"
+                        ],
+                        "type": "string",
+                        "description": "Sort key, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email of the passenger",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last name of the passenger",
+                        "name": "last_name",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/passenger.Passenger"
-                            }
+                            "$ref": "#/definitions/pagination.Page-passenger_Passenger"
                         }
+                    },
+                    "400": {
+                        "description": "Invalid pagination parameters or unknown filter"
                     }
                 }
             }
@@ -359,6 +784,53 @@ const docTemplate = `{
                     "200": {
                         "description": "Passenger deleted"
                     },
+                    "404": {
+                        "description": "Passenger not found"
+                    },
+                    "409": {
+                        "description": "Passenger has tickets"
+                    }
+                }
+            }
+        },
+        "/api/v1/passengers/{id}/role": {
+            "post": {
+                "description": "Changes role of a passenger account, available to admins.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passengers"
+                ],
+                "summary": "Set passenger role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unique identifier of the passenger",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role: passenger, agent or admin",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/passenger.SetRoleReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Role changed"
+                    },
+                    "403": {
+                        "description": "Permission denied"
+                    },
                     "404": {
                         "description": "Passenger not found"
                     }
@@ -427,7 +899,7 @@ const docTemplate = `{
         },
         "/api/v1/tickets": {
             "get": {
-                "description": "get tickets",
+                "description": "get tickets page by page",
                 "consumes": [
                     "application/json"
                 ],
@@ -438,15 +910,67 @@ const docTemplate = `{
                     "tickets"
                 ],
                 "summary": "Get list of tickets",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size, 50 by default, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "booking_time",
+                            "-booking_time",
+                            "departure_time",
+                            "-departure_time"
+                        ],
+                        "type": "string",
+                        "description": "Sort key, prefix with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ticket status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Flight of the ticket",
+                        "name": "flight_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Passenger of the ticket",
+                        "name": "passenger_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Booking locator",
+                        "name": "locator",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/booking.Ticket"
-                            }
+                            "$ref": "#/definitions/pagination.Page-booking_Ticket"
                         }
+                    },
+                    "400": {
+                        "description": "Invalid pagination parameters or unknown filter"
                     }
                 }
             }
@@ -499,6 +1023,12 @@ const docTemplate = `{
                     },
                     "400": {
                         "description": "Invalid ticket data"
+                    },
+                    "403": {
+                        "description": "Ticket or passenger of another account"
+                    },
+                    "409": {
+                        "description": "Flight is sold out, ticket belongs to another passenger or is held on another flight"
                     }
                 }
             }
@@ -518,28 +1048,151 @@ const docTemplate = `{
                 "summary": "Creates ticket",
                 "parameters": [
                     {
-                        "description": "Ticket data",
-                        "name": "flight",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/booking.CreateTicketReq"
-                        }
+                        "description": "Ticket data",
+                        "name": "flight",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/booking.CreateTicketReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ticket created"
+                    },
+                    "400": {
+                        "description": "Invalid ticket data"
+                    },
+                    "409": {
+                        "description": "Flight is sold out"
+                    }
+                }
+            }
+        },
+        "/api/v1/tickets/hold": {
+            "post": {
+                "description": "Creates a held ticket that must be confirmed before the hold expires.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "booking"
+                ],
+                "summary": "Hold a seat",
+                "parameters": [
+                    {
+                        "description": "Hold data",
+                        "name": "hold",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/booking.HoldTicketReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/booking.Ticket"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid hold data"
+                    },
+                    "409": {
+                        "description": "Flight is sold out or seat is taken"
+                    }
+                }
+            }
+        },
+        "/api/v1/tickets/{id}": {
+            "get": {
+                "description": "Returns ticket details for a specific ticket ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tickets"
+                ],
+                "summary": "Get ticket by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unique identifier of the ticket",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/booking.Ticket"
+                        }
+                    },
+                    "404": {
+                        "description": "ticket not found"
+                    }
+                }
+            }
+        },
+        "/api/v1/tickets/{id}/change": {
+            "post": {
+                "description": "Changes the flight associated with a ticket to a new flight using the ticket ID and new flight ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "booking"
+                ],
+                "summary": "Change the flight of a ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The ID of the ticket to update",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The new flight ID to associate with the ticket",
+                        "name": "flightID",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Ticket created"
+                        "description": "Flight successfully changed for the ticket"
                     },
                     "400": {
-                        "description": "Invalid ticket data"
+                        "description": "Invalid parameters"
+                    },
+                    "404": {
+                        "description": "Ticket not found"
+                    },
+                    "409": {
+                        "description": "Flight is sold out or ticket cannot change flight in its current status"
                     }
                 }
             }
         },
-        "/api/v1/tickets/{id}": {
-            "get": {
-                "description": "Returns ticket details for a specific ticket ID.",
+        "/api/v1/tickets/{id}/confirm": {
+            "post": {
+                "description": "Books the held ticket. Fails if the hold has already expired.",
                 "consumes": [
                     "application/json"
                 ],
@@ -547,9 +1200,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tickets"
+                    "booking"
                 ],
-                "summary": "Get ticket by ID",
+                "summary": "Confirm a held seat",
                 "parameters": [
                     {
                         "type": "string",
@@ -561,13 +1214,16 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/booking.Ticket"
-                        }
+                        "description": "Ticket successfully booked"
                     },
                     "404": {
-                        "description": "ticket not found"
+                        "description": "Ticket not found"
+                    },
+                    "409": {
+                        "description": "Ticket is not held"
+                    },
+                    "410": {
+                        "description": "Seat hold has expired"
                     }
                 }
             }
@@ -644,6 +1300,9 @@ const docTemplate = `{
                     },
                     "404": {
                         "description": "Ticket not found"
+                    },
+                    "409": {
+                        "description": "Ticket cannot move to the requested status"
                     }
                 }
             }
@@ -676,54 +1335,64 @@ const docTemplate = `{
                     },
                     "404": {
                         "description": "Ticket not found"
+                    },
+                    "409": {
+                        "description": "Ticket cannot be cancelled in its current status"
+                    }
+                }
+            }
+        }
+
+    },
+    "definitions": {
+        "booking.BookingRecord": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "locator": {
+                    "type": "string"
+                },
+                "tickets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/booking.Ticket"
                     }
                 }
             }
         },
-        "/api/v1/{ticketID}/change": {
-            "post": {
-                "description": "Changes the flight associated with a ticket to a new flight using the ticket ID and new flight ID.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "booking"
-                ],
-                "summary": "Change the flight of a ticket",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "The ID of the ticket to update",
-                        "name": "ticketID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "The new flight ID to associate with the ticket",
-                        "name": "newFlightID",
-                        "in": "query",
-                        "required": true
+        "booking.CheckInReq": {
+            "type": "object",
+            "properties": {
+                "seat_number": {
+                    "type": "string"
+                },
+                "ticket_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "booking.CreateBookingReq": {
+            "type": "object",
+            "properties": {
+                "additional_info": {
+                    "type": "string"
+                },
+                "passenger_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Flight successfully changed for the ticket"
-                    },
-                    "400": {
-                        "description": "Invalid parameters"
-                    },
-                    "404": {
-                        "description": "Ticket not found or cannot change flight for a cancelled ticket"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/booking.SegmentReq"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
+        },
         "booking.CreateTicketReq": {
             "type": "object",
             "properties": {
@@ -747,6 +1416,60 @@ const docTemplate = `{
                 }
             }
         },
+        "booking.HoldTicketReq": {
+            "type": "object",
+            "properties": {
+                "additional_info": {
+                    "type": "string"
+                },
+                "flight_id": {
+                    "type": "string"
+                },
+                "passenger_id": {
+                    "type": "string"
+                },
+                "seat_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "booking.SegmentReq": {
+            "type": "object",
+            "properties": {
+                "flight_id": {
+                    "type": "string"
+                },
+                "seats": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "booking.Status": {
+            "type": "string",
+            "enum": [
+                "held",
+                "booked",
+                "confirmed",
+                "checked_in",
+                "boarded",
+                "cancelled",
+                "refunded",
+                "no_show"
+            ],
+            "x-enum-varnames": [
+                "StatusHeld",
+                "StatusBooked",
+                "StatusConfirmed",
+                "StatusCheckedIn",
+                "StatusBoarded",
+                "StatusCancelled",
+                "StatusRefunded",
+                "StatusNoShow"
+            ]
+        },
         "booking.Ticket": {
             "type": "object",
             "properties": {
@@ -765,9 +1488,15 @@ const docTemplate = `{
                 "flight_id": {
                     "type": "string"
                 },
+                "hold_expires_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "locator": {
+                    "type": "string"
+                },
                 "passenger_id": {
                     "type": "string"
                 },
@@ -775,7 +1504,30 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "status": {
-                    "description": "\"booked\", \"cancelled\", \"confirmed\"",
+                    "$ref": "#/definitions/booking.Status"
+                }
+            }
+        },
+        "flights.CabinLayout": {
+            "type": "object",
+            "properties": {
+                "class": {
+                    "type": "string"
+                },
+                "exit_rows": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "first_row": {
+                    "type": "integer"
+                },
+                "last_row": {
+                    "type": "integer"
+                },
+                "layout": {
+                    "description": "seat letters with aisles as spaces, e.g. \"ABC DEF\"",
                     "type": "string"
                 }
             }
@@ -789,6 +1541,9 @@ const docTemplate = `{
                 "arrival": {
                     "type": "string"
                 },
+                "capacity": {
+                    "type": "integer"
+                },
                 "departure": {
                     "type": "string"
                 },
@@ -800,6 +1555,9 @@ const docTemplate = `{
                 },
                 "price": {
                     "type": "number"
+                },
+                "seat_map": {
+                    "$ref": "#/definitions/flights.SeatMap"
                 }
             }
         },
@@ -813,6 +1571,19 @@ const docTemplate = `{
                 "arrival": {
                     "type": "string"
                 },
+                "available": {
+                    "description": "seats left for booking",
+                    "type": "integer"
+                },
+                "available_by_class": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "capacity": {
+                    "type": "integer"
+                },
                 "departure": {
                     "type": "string"
                 },
@@ -827,6 +1598,151 @@ const docTemplate = `{
                 },
                 "price": {
                     "type": "number"
+                },
+                "seat_map": {
+                    "$ref": "#/definitions/flights.SeatMap"
+                }
+            }
+        },
+        "flights.Itinerary": {
+            "type": "object",
+            "properties": {
+                "duration_minutes": {
+                    "description": "from first departure to last arrival",
+                    "type": "integer"
+                },
+                "legs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/flights.Flight"
+                    }
+                },
+                "stops": {
+                    "description": "intermediate airports of connection search",
+                    "type": "integer"
+                },
+                "total_price": {
+                    "type": "number"
+                }
+            }
+        },
+        "flights.ItineraryReq": {
+            "type": "object",
+            "properties": {
+                "legs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/flights.LegReq"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                }
+            }
+        },
+        "flights.LegReq": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "departure date, YYYY-MM-DD",
+                    "type": "string"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "origin": {
+                    "type": "string"
+                }
+            }
+        },
+        "flights.SeatAvailability": {
+            "type": "object",
+            "properties": {
+                "aisle": {
+                    "type": "boolean"
+                },
+                "available": {
+                    "type": "boolean"
+                },
+                "class": {
+                    "type": "string"
+                },
+                "exit_row": {
+                    "type": "boolean"
+                },
+                "letter": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                },
+                "window": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "flights.SeatMap": {
+            "type": "object",
+            "properties": {
+                "cabins": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/flights.CabinLayout"
+                    }
+                }
+            }
+        },
+        "pagination.Page-booking_Ticket": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/booking.Ticket"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.Page-flights_Flight": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/flights.Flight"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.Page-passenger_Passenger": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/passenger.Passenger"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
@@ -841,6 +1757,9 @@ const docTemplate = `{
                 },
                 "last_name": {
                     "type": "string"
+                },
+                "password": {
+                    "type": "string"
                 }
             }
         },
@@ -857,13 +1776,22 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "id": {
-                    "type": "string"
+                    "type": "string",
+                    "format": "uuid"
                 },
                 "last_name": {
                     "type": "string"
                 },
-                "number": {
-                    "type": "integer"
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "passenger.SetRoleReq": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string"
                 }
             }
         }
@@ -0,0 +1,148 @@
+// Package apperr classifies errors of the service by kind, such as not found or conflict,
+// and gives them stable machine-readable codes, so the API maps them to responses in one place.
+package apperr
+
+import (
+	"errors"
+	"strings"
+)
+
+// Kinds of errors, errors of a kind match it with errors.Is.
+var (
+	ErrValidation    = errors.New("validation failed")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrGone          = errors.New("gone")
+	ErrUnprocessable = errors.New("unprocessable")
+)
+
+// CodeValidation is code of validation errors that have no code of their own.
+const CodeValidation = "validation_failed"
+
+// Error is error of a kind with code, like ticket not found or seat taken.
+// Packages declare them as sentinels, wrapping with fmt.Errorf and %w keeps kind and code.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+// New creates error of kind with code and message shown to the client.
+func New(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is makes Error match its kind.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// FieldError describes invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists invalid fields of a request, it matches ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Field returns validation error of a single invalid field.
+func Field(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add adds invalid field to the error.
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns e if it has invalid fields, nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		fields = append(fields, field.Field+": "+field.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(fields, "; ")
+}
+
+// Is makes ValidationError match ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Invalid marks err as validation error of the request as a whole,
+// like parameters that cannot be combined, keeping its message.
+func Invalid(err error) error {
+	if errors.Is(err, ErrValidation) {
+		return err
+	}
+	return &invalidError{err}
+}
+
+type invalidError struct {
+	err error
+}
+
+func (e *invalidError) Error() string {
+	return e.err.Error()
+}
+
+func (e *invalidError) Unwrap() []error {
+	return []error{ErrValidation, e.err}
+}
+
+// Code returns code of the first Error in chain of err, validation_failed for other validation errors,
+// and empty string for errors of no kind.
+func Code(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	if errors.Is(err, ErrValidation) {
+		return CodeValidation
+	}
+	return ""
+}
+
+// Message returns message of err shown to the client: message of the first Error in chain of err,
+// so details wrapped around it stay in logs, or message of validation error of the request.
+// Errors of no kind have no message.
+func Message(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	var invalid *invalidError
+	if errors.As(err, &invalid) {
+		return invalid.Error()
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Error()
+	}
+	return ""
+}
+
+// Fields returns invalid fields of validation error in chain of err.
+func Fields(err error) []FieldError {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+	return nil
+}
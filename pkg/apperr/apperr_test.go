@@ -0,0 +1,46 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKindAndCode(t *testing.T) {
+	errTicketNotFound := New(ErrNotFound, "ticket_not_found", "ticket not found")
+	err := fmt.Errorf("%w: 42", errTicketNotFound)
+
+	assert.ErrorIs(t, err, errTicketNotFound)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "ticket_not_found", Code(err))
+	assert.Equal(t, "ticket not found: 42", err.Error())
+	assert.Equal(t, "ticket not found", Message(err))
+
+	assert.Equal(t, "", Code(errors.New("connection refused")))
+	assert.Equal(t, "", Message(errors.New("connection refused")))
+}
+
+func TestValidationError(t *testing.T) {
+	var invalid ValidationError
+	assert.NoError(t, invalid.Err())
+
+	invalid.Add("price", "must not be negative")
+	invalid.Add("origin", "is required")
+	err := invalid.Err()
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "validation failed: price: must not be negative; origin: is required", err.Error())
+	assert.Equal(t, CodeValidation, Code(err))
+	assert.Len(t, Fields(fmt.Errorf("create flight: %w", err)), 2)
+	assert.Equal(t, err.Error(), Message(fmt.Errorf("create flight: %w", err)))
+
+	err = Invalid(errors.New("max price is lower than min price"))
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "max price is lower than min price", err.Error())
+	assert.Equal(t, "max price is lower than min price", Message(err))
+	assert.Equal(t, CodeValidation, Code(err))
+	assert.Empty(t, Fields(err))
+	assert.Same(t, err, Invalid(err))
+}
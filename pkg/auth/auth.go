@@ -0,0 +1,325 @@
+// Package auth issues and verifies tokens of passenger accounts.
+// Short-lived access tokens are JWTs signed with a key of Keyring, each comes with an opaque refresh token stored in the database.
+// Refresh tokens rotate: a refresh token is exchanged for a new pair once, and using it again revokes the session.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"flightticketservice/pkg/apperr"
+	"flightticketservice/pkg/passenger"
+	"flightticketservice/pkg/validate"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Defaults of Config.
+const (
+	DefaultIssuer     = "flightticketservice"
+	DefaultAudience   = "flightticketservice"
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// Token errors.
+var (
+	ErrInvalidToken        = apperr.New(apperr.ErrUnauthorized, "invalid_token", "missing or invalid token")
+	ErrTokenExpired        = apperr.New(apperr.ErrUnauthorized, "token_expired", "token has expired")
+	ErrTokenRevoked        = apperr.New(apperr.ErrUnauthorized, "token_revoked", "token has been revoked")
+	ErrInvalidRefreshToken = apperr.New(apperr.ErrUnauthorized, "invalid_refresh_token", "refresh token is invalid or expired")
+	ErrRefreshTokenReused  = apperr.New(apperr.ErrUnauthorized, "refresh_token_reused", "refresh token was already used, the session is revoked")
+)
+
+// Config collects settings of issued tokens.
+type Config struct {
+	Keys       *Keyring // keys that sign and verify access tokens
+	Issuer     string
+	Audience   string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// Claims are claims of access token, subject is passenger UUID.
+type Claims struct {
+	Role      passenger.Role `json:"role"`
+	SessionID string         `json:"sid"` // family of refresh tokens the token was issued with
+	jwt.RegisteredClaims
+}
+
+// Pair is access token with refresh token to get the next pair when it expires.
+type Pair struct {
+	AccessToken      string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// RefreshReq collects refresh token to exchange for a new pair.
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Validate checks fields of the request.
+func (r *RefreshReq) Validate() error {
+	return validate.All(
+		validate.Field("refresh_token", r.RefreshToken, validate.Required[string]()),
+	)
+}
+
+// Passengers looks up accounts, refreshed tokens carry the current role of the account.
+type Passengers interface {
+	GetPassengerByID(ctx context.Context, passengerID string) (*passenger.Passenger, error)
+}
+
+// Service issues, refreshes and revokes tokens.
+type Service struct {
+	cfg        Config
+	store      Store
+	passengers Passengers
+	now        func() time.Time
+}
+
+// NewService creates token service, zero fields of cfg except Keys take defaults.
+func NewService(cfg Config, store Store, passengers Passengers) (*Service, error) {
+	if cfg.Keys == nil {
+		return nil, errors.New("token keys are required")
+	}
+	if _, err := cfg.Keys.SigningKey(time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = DefaultIssuer
+	}
+	if cfg.Audience == "" {
+		cfg.Audience = DefaultAudience
+	}
+	if cfg.AccessTTL == 0 {
+		cfg.AccessTTL = DefaultAccessTTL
+	}
+	if cfg.RefreshTTL == 0 {
+		cfg.RefreshTTL = DefaultRefreshTTL
+	}
+
+	return &Service{
+		cfg:        cfg,
+		store:      store,
+		passengers: passengers,
+		now:        func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+// Issue starts a new session of the passenger and returns its first pair of tokens.
+func (s *Service) Issue(ctx context.Context, pass *passenger.Passenger) (*Pair, error) {
+	return s.issue(ctx, pass, randomID())
+}
+
+// issue returns pair of tokens of the session, refresh token is stored before it is returned.
+func (s *Service) issue(ctx context.Context, pass *passenger.Passenger, sessionID string) (*Pair, error) {
+	now := s.now()
+	claims := &Claims{
+		Role:      pass.Role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        randomID(),
+			Subject:   pass.ID,
+			Issuer:    s.cfg.Issuer,
+			Audience:  jwt.ClaimStrings{s.cfg.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.AccessTTL)),
+		},
+	}
+
+	key, err := s.cfg.Keys.SigningKey(now)
+	if err != nil {
+		return nil, err
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	signingKey := any(key.private)
+	if key.secret != nil {
+		signingKey = key.secret
+	} else {
+		token.Header["kid"] = key.ID
+	}
+	accessToken, err := token.SignedString(signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("sign access token: %w", err)
+	}
+
+	refreshToken := randomToken()
+	stored := &RefreshToken{
+		Hash:        hashToken(refreshToken),
+		SessionID:   sessionID,
+		PassengerID: pass.ID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.cfg.RefreshTTL),
+	}
+	if err := s.store.CreateRefreshToken(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return &Pair{
+		AccessToken:      accessToken,
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+// Verify checks signature, issuer, audience and expiry of access token and that it is not revoked.
+// The token is verified with key of its kid header, the algorithm of the header has to be the algorithm of the key.
+func (s *Service) Verify(ctx context.Context, accessToken string) (*Claims, error) {
+	claims := new(Claims)
+	_, err := jwt.ParseWithClaims(accessToken, claims,
+		func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			return s.cfg.Keys.verificationKey(kid, token.Method)
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), AlgRS256, AlgEdDSA}),
+		jwt.WithIssuer(s.cfg.Issuer),
+		jwt.WithAudience(s.cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(s.now),
+	)
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, fmt.Errorf("%w: %w", ErrTokenExpired, err)
+	case err != nil:
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	case uuid.Validate(claims.Subject) != nil || claims.ID == "" || !slices.Contains(passenger.Roles, claims.Role):
+		// tokens issued before passengers got UUIDs are rejected, clients refresh them
+		return nil, fmt.Errorf("%w: token has no passenger UUID, ID or role", ErrInvalidToken)
+	}
+
+	revoked, err := s.store.AccessTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// Refresh exchanges refresh token for a new pair of the same session.
+// A refresh token used for the second time is taken as stolen, every refresh token of its session is revoked.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Pair, error) {
+	now := s.now()
+	hash := hashToken(refreshToken)
+
+	stored, err := s.store.GetRefreshToken(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if stored.RevokedAt != nil || !now.Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	reused := stored.UsedAt != nil
+	if !reused {
+		err := s.store.UseRefreshToken(ctx, hash, now)
+		reused = errors.Is(err, errRefreshTokenUsed)
+		if err != nil && !reused {
+			return nil, err
+		}
+	}
+	if reused {
+		if err := s.store.RevokeSession(ctx, stored.SessionID, now); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: session %s", ErrRefreshTokenReused, stored.SessionID)
+	}
+
+	pass, err := s.passengers.GetPassengerByID(ctx, stored.PassengerID)
+	if errors.Is(err, passenger.ErrPassengerNotFound) {
+		return nil, fmt.Errorf("%w: passenger %s is deleted", ErrInvalidRefreshToken, stored.PassengerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, pass, stored.SessionID)
+}
+
+// Revoke ends session of access token: the token is rejected until it expires and refresh tokens of the session are revoked.
+func (s *Service) Revoke(ctx context.Context, claims *Claims) error {
+	if err := s.store.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+	return s.store.RevokeSession(ctx, claims.SessionID, s.now())
+}
+
+// JWKS returns public keys that verify access tokens.
+func (s *Service) JWKS() JWKSet {
+	return s.cfg.Keys.JWKS()
+}
+
+// randomID returns random hex ID of token or session.
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// randomToken returns random opaque refresh token.
+func randomToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// hashToken returns hash of refresh token, only hashes are stored so a database leak does not leak sessions.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeleteExpired deletes expired refresh tokens and revoked access tokens, they are rejected anyway.
+func (s *Service) DeleteExpired(ctx context.Context) (int64, error) {
+	return s.store.DeleteExpired(ctx, s.now())
+}
+
+// Reaper periodically deletes expired refresh tokens and revoked access tokens.
+type Reaper struct {
+	tokens   *Service
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewReaper creates reaper that deletes expired tokens every interval.
+func NewReaper(tokens *Service, interval time.Duration, logger *slog.Logger) *Reaper {
+	return &Reaper{tokens: tokens, interval: interval, logger: logger}
+}
+
+// Run deletes expired tokens every interval until ctx is cancelled.
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.InfoContext(ctx, "token reaper stopped")
+			return
+		case <-ticker.C:
+			deleted, err := r.tokens.DeleteExpired(ctx)
+			if err != nil {
+				r.logger.ErrorContext(ctx, "failed to delete expired tokens", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				r.logger.InfoContext(ctx, "deleted expired tokens", "count", deleted)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flightticketservice/pkg/passenger"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestService(t *testing.T) (*Service, *passenger.Passenger, *time.Time) {
+	passengers := passenger.NewMemoryStore()
+	john := &passenger.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com", Role: passenger.RolePassenger}
+	assert.NoError(t, passengers.CreatePassenger(context.Background(), john))
+
+	keys, err := NewSecretKeyring([]byte("secret"))
+	assert.NoError(t, err)
+	service, err := NewService(Config{Keys: keys}, NewMemoryStore(), passengers)
+	assert.NoError(t, err)
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service, john, &now
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	ctx := context.Background()
+	service, john, now := newTestService(t)
+
+	pair, err := service.Issue(ctx, john)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(DefaultAccessTTL), pair.ExpiresAt)
+	assert.Equal(t, now.Add(DefaultRefreshTTL), pair.RefreshExpiresAt)
+
+	claims, err := service.Verify(ctx, pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, john.ID, claims.Subject)
+	assert.Equal(t, passenger.RolePassenger, claims.Role)
+	assert.Equal(t, DefaultIssuer, claims.Issuer)
+
+	*now = now.Add(DefaultAccessTTL + time.Second)
+	_, err = service.Verify(ctx, pair.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	_, err = NewService(Config{}, NewMemoryStore(), nil)
+	assert.Error(t, err)
+}
+
+func TestVerifyRejectsForeignTokens(t *testing.T) {
+	ctx := context.Background()
+	service, john, now := newTestService(t)
+
+	sign := func(claims *Claims, secret string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		assert.NoError(t, err)
+		return token
+	}
+	claims := func(issuer string) *Claims {
+		return &Claims{Role: passenger.RoleAdmin, RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "1",
+			Subject:   john.ID,
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{DefaultAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		}}
+	}
+
+	_, err := service.Verify(ctx, sign(claims(DefaultIssuer), "other secret"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = service.Verify(ctx, sign(claims("someone else"), "secret"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	noExpiry := claims(DefaultIssuer)
+	noExpiry.ExpiresAt = nil
+	_, err = service.Verify(ctx, sign(noExpiry, "secret"))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = service.Verify(ctx, "")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	numeric := claims(DefaultIssuer)
+	numeric.Subject = "42"
+	_, err = service.Verify(ctx, sign(numeric, "secret"))
+	assert.ErrorIs(t, err, ErrInvalidToken, "tokens of serial passenger ids are refreshed")
+
+	_, err = service.Verify(ctx, sign(claims(DefaultIssuer), "secret"))
+	assert.NoError(t, err)
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	ctx := context.Background()
+	service, john, now := newTestService(t)
+
+	first, err := service.Issue(ctx, john)
+	assert.NoError(t, err)
+
+	*now = now.Add(time.Minute)
+	second, err := service.Refresh(ctx, first.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	firstClaims, err := service.Verify(ctx, first.AccessToken)
+	assert.NoError(t, err)
+	secondClaims, err := service.Verify(ctx, second.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, firstClaims.SessionID, secondClaims.SessionID)
+
+	// reuse of the first refresh token revokes the session, including the second refresh token
+	_, err = service.Refresh(ctx, first.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = service.Refresh(ctx, second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	_, err = service.Refresh(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	third, err := service.Issue(ctx, john)
+	assert.NoError(t, err)
+	*now = now.Add(DefaultRefreshTTL)
+	_, err = service.Refresh(ctx, third.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	service, john, now := newTestService(t)
+
+	pair, err := service.Issue(ctx, john)
+	assert.NoError(t, err)
+	claims, err := service.Verify(ctx, pair.AccessToken)
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Revoke(ctx, claims))
+	_, err = service.Verify(ctx, pair.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = service.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	deleted, err := service.store.DeleteExpired(ctx, now.Add(DefaultRefreshTTL))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
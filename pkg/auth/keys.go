@@ -0,0 +1,455 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms of asymmetric keys.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// rsaKeyBits is size of generated RSA keys.
+const rsaKeyBits = 2048
+
+// JWKSMaxAge is how long verifiers may cache published keys. A new key is published that long before it signs,
+// so verifiers that fetched keys before the rotation are not given tokens of a key they do not know.
+const JWKSMaxAge = 5 * time.Minute
+
+// Key is a key of the keyring. Keys without private part, e.g. public keys of retiring keys of another instance,
+// only verify tokens.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	CreatedAt time.Time // when the key started signing, keys are ordered by it
+	private   crypto.Signer
+	public    crypto.PublicKey
+	secret    []byte // HMAC key of secret keyrings
+}
+
+// JWK is public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`   // RSA modulus
+	E         string `json:"e,omitempty"`   // RSA exponent
+	Curve     string `json:"crv,omitempty"` // OKP curve
+	X         string `json:"x,omitempty"`   // OKP public key
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Keyring holds keys that sign and verify access tokens.
+// Tokens are signed with the newest key, older keys verify tokens signed before the rotation until they are retired.
+type Keyring struct {
+	mu   sync.RWMutex
+	dir  string // directory of PEM files, empty for keyrings kept in memory
+	alg  string // algorithm of generated keys
+	keys []*Key // ordered by CreatedAt
+}
+
+// NewSecretKeyring creates keyring with a single HS256 key, tokens it signs are verified only with the same secret.
+// Such tokens have no kid header and the keyring publishes no keys.
+func NewSecretKeyring(secret []byte) (*Keyring, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("token secret is required")
+	}
+	return &Keyring{keys: []*Key{{Method: jwt.SigningMethodHS256, secret: secret}}}, nil
+}
+
+// NewKeyring creates keyring with a generated key of alg kept only in memory,
+// tokens it signs cannot be verified after restart.
+func NewKeyring(alg string) (*Keyring, error) {
+	kr := &Keyring{alg: alg}
+	if _, err := kr.Rotate(time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// LoadKeyring loads keys from PEM files of dir, alg is algorithm of keys generated on rotation.
+// ID of a key is name of its file without .pem extension, the modification time of the file is when it started signing.
+// Files hold PKCS#8 or PKCS#1 private keys, or public keys that only verify tokens. A missing dir is created.
+func LoadKeyring(dir, alg string) (*Keyring, error) {
+	if alg != AlgRS256 && alg != AlgEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %q, use %s or %s", alg, AlgRS256, AlgEdDSA)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create keys directory: %w", err)
+	}
+
+	kr := &Keyring{dir: dir, alg: alg}
+	if err := kr.Reload(); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// Reload replaces keys of the keyring with keys of its directory, e.g. added by an operator or another instance.
+func (kr *Keyring) Reload() error {
+	if kr.dir == "" {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(kr.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		key, err := readKey(path)
+		if err != nil {
+			return fmt.Errorf("load key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	sortKeys(keys)
+
+	kr.mu.Lock()
+	kr.keys = keys
+	kr.mu.Unlock()
+	return nil
+}
+
+// Rotate generates a new key and writes it to the directory of the keyring, it signs after JWKSMaxAge.
+// Previous keys keep verifying tokens until Retire removes them.
+func (kr *Keyring) Rotate(now time.Time) (*Key, error) {
+	key, block, err := generateKey(kr.alg, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if kr.dir != "" {
+		path := filepath.Join(kr.dir, key.ID+".pem")
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+			return nil, fmt.Errorf("write key: %w", err)
+		}
+		if err := os.Chtimes(path, now, now); err != nil {
+			return nil, fmt.Errorf("write key: %w", err)
+		}
+	}
+
+	kr.mu.Lock()
+	kr.keys = append(kr.keys, key)
+	sortKeys(kr.keys)
+	kr.mu.Unlock()
+	return key, nil
+}
+
+// Retire removes keys that were replaced by a newer key that started signing more than overlap ago, their files are deleted.
+// Keys with private part are replaced only by keys with private part, the key signing at now is kept however old it is.
+// Overlap should be at least access token TTL so that tokens signed by a replaced key stay valid until they expire.
+func (kr *Keyring) Retire(now time.Time, overlap time.Duration) ([]string, error) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	var (
+		retired    []string
+		errs       []error
+		kept       = make([]*Key, 0, len(kr.keys))
+		signing, _ = kr.signingKey(now)
+	)
+	for i, key := range kr.keys {
+		if next := kr.replacement(i); key != signing && next != nil && now.Sub(next.CreatedAt.Add(JWKSMaxAge)) > overlap {
+			var err error
+			if kr.dir != "" {
+				err = os.Remove(filepath.Join(kr.dir, key.ID+".pem"))
+			}
+			if err == nil || errors.Is(err, os.ErrNotExist) {
+				retired = append(retired, key.ID)
+				continue
+			}
+			errs = append(errs, fmt.Errorf("retire key %s: %w", key.ID, err))
+		}
+		kept = append(kept, key)
+	}
+	kr.keys = kept
+	return retired, errors.Join(errs...)
+}
+
+// SigningKey returns key that signs tokens at now: the newest key with private part published for JWKSMaxAge,
+// or the newest key with private part if none was published that long, like the first key of a keyring.
+func (kr *Keyring) SigningKey(now time.Time) (*Key, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.signingKey(now)
+}
+
+// signingKey returns key that signs tokens at now, mu must be held.
+func (kr *Keyring) signingKey(now time.Time) (*Key, error) {
+	var newest *Key
+	for _, key := range slices.Backward(kr.keys) {
+		if key.private == nil && key.secret == nil {
+			continue
+		}
+		if !now.Before(key.CreatedAt.Add(JWKSMaxAge)) {
+			return key, nil
+		}
+		if newest == nil {
+			newest = key
+		}
+	}
+	if newest == nil {
+		return nil, errors.New("keyring has no signing key")
+	}
+	return newest, nil
+}
+
+// replacement returns the oldest key newer than key i that replaces it, nil if there is none, mu must be held.
+// Keys with private part are replaced only by keys with private part, other keys by any newer key.
+func (kr *Keyring) replacement(i int) *Key {
+	for _, key := range kr.keys[i+1:] {
+		if kr.keys[i].private == nil || key.private != nil {
+			return key
+		}
+	}
+	return nil
+}
+
+// verificationKey returns key that verifies token signed by key with ID kid using method.
+func (kr *Keyring) verificationKey(kid string, method jwt.SigningMethod) (any, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	for _, key := range kr.keys {
+		if key.ID != kid {
+			continue
+		}
+		if key.Method.Alg() != method.Alg() {
+			return nil, fmt.Errorf("key %q does not sign with %s", kid, method.Alg())
+		}
+		if key.secret != nil {
+			return key.secret, nil
+		}
+		return key.public, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// JWKS returns public keys of the keyring.
+func (kr *Keyring) JWKS() JWKSet {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range kr.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// newest returns when the newest key started signing, zero time for an empty keyring.
+func (kr *Keyring) newest() time.Time {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	if len(kr.keys) == 0 {
+		return time.Time{}
+	}
+	return kr.keys[len(kr.keys)-1].CreatedAt
+}
+
+// readKey parses key of PEM file.
+func readKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), ".pem"), CreatedAt: info.ModTime().UTC()}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		parsed = signer.Public()
+	}
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < rsaKeyBits {
+			return nil, fmt.Errorf("RSA key has %d bits, at least %d are required", public.N.BitLen(), rsaKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", parsed)
+	}
+	key.public = parsed
+	return key, nil
+}
+
+// generateKey returns a new key of alg with its PKCS#8 PEM block.
+func generateKey(alg string, now time.Time) (*Key, *pem.Block, error) {
+	var (
+		signer crypto.Signer
+		method jwt.SigningMethod
+		err    error
+	)
+	switch alg {
+	case AlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		method = jwt.SigningMethodRS256
+	case AlgEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode key: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	key := &Key{
+		// IDs start with creation time, so files of a directory list in rotation order
+		ID:        now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix),
+		Method:    method,
+		CreatedAt: now.UTC(),
+		private:   signer,
+		public:    signer.Public(),
+	}
+	return key, &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+}
+
+// sortKeys orders keys by CreatedAt and ID.
+func sortKeys(keys []*Key) {
+	slices.SortStableFunc(keys, func(a, b *Key) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// KeyRotator keeps keyring up to date: it reloads keys of the directory,
+// generates a new signing key when there is none or the newest one is older than the rotation period and retires replaced keys.
+type KeyRotator struct {
+	keys     *Keyring
+	period   time.Duration // zero disables periodic generation of keys, keys are rotated by hand
+	overlap  time.Duration
+	interval time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewKeyRotator creates rotator that checks keyring every interval.
+func NewKeyRotator(keys *Keyring, period, overlap, interval time.Duration, logger *slog.Logger) *KeyRotator {
+	return &KeyRotator{
+		keys:     keys,
+		period:   period,
+		overlap:  overlap,
+		interval: interval,
+		logger:   logger,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Rotate checks keyring once, a keyring without signing key gets one even when rotation is disabled.
+func (r *KeyRotator) Rotate(ctx context.Context) error {
+	if err := r.keys.Reload(); err != nil {
+		return err
+	}
+
+	now := r.now()
+	_, err := r.keys.SigningKey(now)
+	if err != nil || r.period > 0 && now.Sub(r.keys.newest()) >= r.period {
+		key, err := r.keys.Rotate(now)
+		if err != nil {
+			return err
+		}
+		r.logger.InfoContext(ctx, "rotated signing key", "kid", key.ID, "alg", key.Method.Alg())
+	}
+
+	retired, err := r.keys.Retire(now, r.overlap)
+	if len(retired) > 0 {
+		r.logger.InfoContext(ctx, "retired signing keys", "kids", retired)
+	}
+	return err
+}
+
+// Run checks keyring every interval until ctx is cancelled.
+func (r *KeyRotator) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.InfoContext(ctx, "key rotator stopped")
+			return
+		case <-ticker.C:
+			if err := r.Rotate(ctx); err != nil {
+				r.logger.ErrorContext(ctx, "failed to rotate signing keys", "error", err)
+			}
+		}
+	}
+}
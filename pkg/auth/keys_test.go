@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"flightticketservice/utils"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	service, john, now := newTestService(t)
+	dir := t.TempDir()
+
+	keys, err := LoadKeyring(dir, AlgEdDSA)
+	assert.NoError(t, err)
+	_, err = NewService(Config{Keys: keys}, NewMemoryStore(), nil)
+	assert.Error(t, err, "keyring without keys cannot sign")
+
+	rotator := NewKeyRotator(keys, 24*time.Hour, time.Hour, time.Minute, utils.DiscardLogger())
+	rotator.now = func() time.Time { return *now }
+	assert.NoError(t, rotator.Rotate(ctx))
+	first, err := keys.SigningKey(*now)
+	assert.NoError(t, err, "the first key signs at once")
+	service.cfg.Keys = keys
+
+	*now = now.Add(24*time.Hour - time.Minute)
+	old, err := service.Issue(ctx, john)
+	assert.NoError(t, err)
+	assert.NoError(t, rotator.Rotate(ctx))
+	second, _ := keys.SigningKey(*now)
+	assert.Equal(t, first, second, "rotation is not due yet")
+
+	// a new key is published before it signs, so verifiers caching keys learn it first
+	*now = now.Add(time.Minute)
+	assert.NoError(t, rotator.Rotate(ctx))
+	assert.Len(t, keys.JWKS().Keys, 2)
+	second, _ = keys.SigningKey(*now)
+	assert.Equal(t, first, second)
+	*now = now.Add(JWKSMaxAge)
+	second, _ = keys.SigningKey(*now)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	// tokens of the replaced key stay valid during the overlap
+	_, err = service.Verify(ctx, old.AccessToken)
+	assert.NoError(t, err)
+	pair, err := service.Issue(ctx, john)
+	assert.NoError(t, err)
+	token, _, err := jwt.NewParser().ParseUnverified(pair.AccessToken, &Claims{})
+	assert.NoError(t, err)
+	assert.Equal(t, second.ID, token.Header["kid"])
+	assert.Equal(t, AlgEdDSA, token.Method.Alg())
+
+	*now = now.Add(time.Hour + time.Second)
+	assert.NoError(t, rotator.Rotate(ctx))
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, JWK{KeyType: "OKP", KeyID: second.ID, Use: "sig", Algorithm: AlgEdDSA, Curve: "Ed25519", X: jwks.Keys[0].X}, jwks.Keys[0])
+	files, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+	assert.Equal(t, []string{filepath.Join(dir, second.ID+".pem")}, files)
+
+	// another instance loads the same keys from the directory
+	pair, err = service.Issue(ctx, john)
+	assert.NoError(t, err)
+	reloaded, err := LoadKeyring(dir, AlgEdDSA)
+	assert.NoError(t, err)
+	service.cfg.Keys = reloaded
+	_, err = service.Verify(ctx, pair.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestKeyRotationDisabled(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	keys, err := LoadKeyring(dir, AlgEdDSA)
+	assert.NoError(t, err)
+	rotator := NewKeyRotator(keys, 0, time.Hour, time.Minute, utils.DiscardLogger())
+
+	// an empty directory gets its first key without rotation period
+	assert.NoError(t, rotator.Rotate(ctx))
+	first, err := keys.SigningKey(time.Now())
+	assert.NoError(t, err)
+
+	rotator.now = func() time.Time { return first.CreatedAt.Add(365 * 24 * time.Hour) }
+	assert.NoError(t, rotator.Rotate(ctx))
+	second, err := keys.SigningKey(first.CreatedAt.Add(365 * 24 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID, "keys are not rotated")
+	files, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+	assert.Len(t, files, 1)
+}
+
+func TestRetireKeepsSigningKey(t *testing.T) {
+	dir := t.TempDir()
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	writeKey := func(name string, at time.Time, public bool) {
+		key, block, err := generateKey(AlgEdDSA, at)
+		assert.NoError(t, err)
+		if public {
+			der, err := x509.MarshalPKIXPublicKey(key.public)
+			assert.NoError(t, err)
+			block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+		}
+		path := filepath.Join(dir, name+".pem")
+		assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+		assert.NoError(t, os.Chtimes(path, at, at))
+	}
+
+	// a newer key of another instance only verifies tokens, it does not replace the signing key
+	writeKey("own", created, false)
+	writeKey("other", created.Add(time.Hour), true)
+	keys, err := LoadKeyring(dir, AlgEdDSA)
+	assert.NoError(t, err)
+	now := created.Add(48 * time.Hour)
+	retired, err := keys.Retire(now, time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, retired)
+	signing, err := keys.SigningKey(now)
+	assert.NoError(t, err)
+	assert.Equal(t, "own", signing.ID)
+
+	// a key that does not sign yet does not retire the signing key
+	writeKey("next", now.Add(-time.Minute), false)
+	assert.NoError(t, keys.Reload())
+	retired, err = keys.Retire(now, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, retired)
+	signing, _ = keys.SigningKey(now)
+	assert.Equal(t, "own", signing.ID)
+
+	retired, err = keys.Retire(now.Add(JWKSMaxAge+time.Second), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"own", "other"}, retired)
+}
+
+func TestRSAKeys(t *testing.T) {
+	ctx := context.Background()
+	service, john, _ := newTestService(t)
+	dir := t.TempDir()
+
+	private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	assert.NoError(t, err)
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.pem"), pem.EncodeToMemory(block), 0o600))
+
+	keys, err := LoadKeyring(dir, AlgRS256)
+	assert.NoError(t, err)
+	service.cfg.Keys = keys
+	pair, err := service.Issue(ctx, john)
+	assert.NoError(t, err)
+	_, err = service.Verify(ctx, pair.AccessToken)
+	assert.NoError(t, err)
+
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	assert.Equal(t, "main", jwks.Keys[0].KeyID)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+
+	// HMAC signed with the public key must not pass as the RSA key
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	assert.NoError(t, err)
+	claims, err := service.Verify(ctx, pair.AccessToken)
+	assert.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "main"
+	signed, err := forged.SignedString(publicDER)
+	assert.NoError(t, err)
+	_, err = service.Verify(ctx, signed)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = LoadKeyring(dir, "HS256")
+	assert.Error(t, err)
+}
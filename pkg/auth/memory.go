@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps tokens in memory, it implements Store without a database.
+type MemoryStore struct {
+	mu      sync.Mutex
+	refresh map[string]*RefreshToken
+	revoked map[string]time.Time // expiry of revoked access tokens by ID
+}
+
+// NewMemoryStore creates empty in-memory token store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{refresh: map[string]*RefreshToken{}, revoked: map[string]time.Time{}}
+}
+
+// CreateRefreshToken stores refresh token
+func (ms *MemoryStore) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stored := *token
+	ms.refresh[token.Hash] = &stored
+	return nil
+}
+
+// GetRefreshToken returns refresh token by hash
+func (ms *MemoryStore) GetRefreshToken(ctx context.Context, hash string) (*RefreshToken, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	token, ok := ms.refresh[hash]
+	if !ok {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	copied := *token
+	return &copied, nil
+}
+
+// UseRefreshToken marks refresh token as used
+func (ms *MemoryStore) UseRefreshToken(ctx context.Context, hash string, now time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	token, ok := ms.refresh[hash]
+	if !ok || token.UsedAt != nil {
+		return errRefreshTokenUsed
+	}
+
+	token.UsedAt = &now
+	return nil
+}
+
+// RevokeSession revokes every refresh token of the session
+func (ms *MemoryStore) RevokeSession(ctx context.Context, sessionID string, now time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, token := range ms.refresh {
+		if token.SessionID == sessionID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// RevokeAccessToken adds access token to the revocation list
+func (ms *MemoryStore) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.revoked[tokenID] = expiresAt
+	return nil
+}
+
+// AccessTokenRevoked reports if access token is in the revocation list
+func (ms *MemoryStore) AccessTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	_, ok := ms.revoked[tokenID]
+	return ok, nil
+}
+
+// DeleteExpired deletes expired tokens
+func (ms *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var deleted int64
+	for hash, token := range ms.refresh {
+		if !now.Before(token.ExpiresAt) {
+			delete(ms.refresh, hash)
+			deleted++
+		}
+	}
+	for id, expiresAt := range ms.revoked {
+		if !now.Before(expiresAt) {
+			delete(ms.revoked, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"flightticketservice/pkg/database"
+	"flightticketservice/utils"
+
+	"go.opentelemetry.io/otel"
+)
+
+// tracer starts spans of store methods, statements they run are traced by the database driver.
+var tracer = otel.Tracer("flightticketservice/pkg/auth")
+
+// errRefreshTokenUsed is returned by UseRefreshToken when the token was used concurrently.
+var errRefreshTokenUsed = errors.New("refresh token is already used")
+
+// RefreshToken is stored refresh token, the token itself is known only to the client.
+type RefreshToken struct {
+	Hash        string
+	SessionID   string
+	PassengerID string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	UsedAt      *time.Time // set when the token is exchanged for the next pair
+	RevokedAt   *time.Time // set on logout or reuse of a token of the session
+}
+
+// Store keeps refresh tokens and the list of revoked access tokens.
+type Store interface {
+	CreateRefreshToken(ctx context.Context, token *RefreshToken) error
+	// GetRefreshToken returns ErrInvalidRefreshToken if there is no token with hash.
+	GetRefreshToken(ctx context.Context, hash string) (*RefreshToken, error)
+	// UseRefreshToken marks unused token as used, it returns errRefreshTokenUsed if the token is already used.
+	UseRefreshToken(ctx context.Context, hash string, now time.Time) error
+	RevokeSession(ctx context.Context, sessionID string, now time.Time) error
+	// RevokeAccessToken adds access token ID to the revocation list until the token expires.
+	RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	AccessTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	// DeleteExpired deletes expired refresh tokens and revoked access tokens, it returns number of deleted tokens.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// PostgresStore keeps tokens in refresh_tokens and revoked_tokens tables.
+type PostgresStore struct {
+	db database.Querier
+}
+
+// NewPostgresStore creates token store on the database.
+func NewPostgresStore(db database.Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// CreateRefreshToken stores refresh token
+func (ps *PostgresStore) CreateRefreshToken(ctx context.Context, token *RefreshToken) (err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.CreateRefreshToken")
+	defer func() { utils.EndSpan(span, err) }()
+
+	_, err = ps.db.ExecContext(ctx,
+		`insert into refresh_tokens (token_hash, session_id, passenger_id, created_at, expires_at)
+		values ($1, $2, $3, $4, $5)`,
+		token.Hash, token.SessionID, token.PassengerID, token.CreatedAt, token.ExpiresAt)
+	return err
+}
+
+// GetRefreshToken returns refresh token by hash
+func (ps *PostgresStore) GetRefreshToken(ctx context.Context, hash string) (_ *RefreshToken, err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.GetRefreshToken")
+	defer func() { utils.EndSpan(span, err) }()
+
+	token := &RefreshToken{Hash: hash}
+	err = ps.db.QueryRowContext(ctx,
+		`select session_id, passenger_id, created_at, expires_at, used_at, revoked_at
+		from refresh_tokens where token_hash = $1`, hash).
+		Scan(&token.SessionID, &token.PassengerID, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt, &token.RevokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// UseRefreshToken marks refresh token as used
+func (ps *PostgresStore) UseRefreshToken(ctx context.Context, hash string, now time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.UseRefreshToken")
+	defer func() { utils.EndSpan(span, err) }()
+
+	res, err := ps.db.ExecContext(ctx,
+		"update refresh_tokens set used_at = $2 where token_hash = $1 and used_at is null", hash, now)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errRefreshTokenUsed
+	}
+	return nil
+}
+
+// RevokeSession revokes every refresh token of the session
+func (ps *PostgresStore) RevokeSession(ctx context.Context, sessionID string, now time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.RevokeSession")
+	defer func() { utils.EndSpan(span, err) }()
+
+	res, err := ps.db.ExecContext(ctx,
+		"update refresh_tokens set revoked_at = $2 where session_id = $1 and revoked_at is null", sessionID, now)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	utils.SetRows(ctx, rows)
+	return nil
+}
+
+// RevokeAccessToken adds access token to the revocation list
+func (ps *PostgresStore) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.RevokeAccessToken")
+	defer func() { utils.EndSpan(span, err) }()
+
+	_, err = ps.db.ExecContext(ctx,
+		"insert into revoked_tokens (token_id, expires_at) values ($1, $2) on conflict (token_id) do nothing",
+		tokenID, expiresAt)
+	return err
+}
+
+// AccessTokenRevoked reports if access token is in the revocation list
+func (ps *PostgresStore) AccessTokenRevoked(ctx context.Context, tokenID string) (revoked bool, err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.AccessTokenRevoked")
+	defer func() { utils.EndSpan(span, err) }()
+
+	err = ps.db.QueryRowContext(ctx,
+		"select exists (select 1 from revoked_tokens where token_id = $1)", tokenID).Scan(&revoked)
+	return revoked, err
+}
+
+// DeleteExpired deletes expired tokens
+func (ps *PostgresStore) DeleteExpired(ctx context.Context, now time.Time) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.DeleteExpired")
+	defer func() { utils.EndSpan(span, err) }()
+
+	var deleted int64
+	for _, table := range []string{"refresh_tokens", "revoked_tokens"} {
+		res, err := ps.db.ExecContext(ctx, "delete from "+table+" where expires_at <= $1", now)
+		if err != nil {
+			return deleted, err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += rows
+	}
+
+	utils.SetRows(ctx, deleted)
+	return deleted, nil
+}
@@ -0,0 +1,44 @@
+package booking
+
+import (
+	"context"
+	"database/sql"
+
+	"flightticketservice/pkg/apperr"
+)
+
+// Capacity errors.
+var (
+	ErrSoldOut        = apperr.New(apperr.ErrConflict, "sold_out", "flight is sold out")
+	ErrFlightNotFound = apperr.New(apperr.ErrNotFound, "flight_not_found", "flight not found")
+)
+
+// reserveSeat locks the flight row and checks that flight has room for one more active ticket.
+// Lock is held until tx ends, so concurrent bookings of the same flight are serialized.
+func reserveSeat(ctx context.Context, tx *sql.Tx, flightID, ticketID string) error {
+	if !validID(flightID) {
+		return ErrFlightNotFound
+	}
+
+	var capacity int
+	err := tx.QueryRowContext(ctx, `select capacity from flights where id = $1 for update`, flightID).Scan(&capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrFlightNotFound
+		}
+		return err
+	}
+
+	var booked int
+	query := `select count(*) from booking_flights
+	where flight_id = $1 and status not in ` + inactiveStatuses + ` and id::text <> $2`
+	if err := tx.QueryRowContext(ctx, query, flightID, ticketID).Scan(&booked); err != nil {
+		return err
+	}
+
+	if booked >= capacity {
+		return ErrSoldOut
+	}
+
+	return nil
+}
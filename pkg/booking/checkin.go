@@ -0,0 +1,60 @@
+package booking
+
+import (
+	"time"
+
+	"flightticketservice/pkg/apperr"
+)
+
+// Check-in errors returned to the client with distinct codes.
+var (
+	ErrCheckInNotOpen     = apperr.New(apperr.ErrUnprocessable, "checkin_not_open", "check-in is not open yet for this flight")
+	ErrCheckInClosed      = apperr.New(apperr.ErrUnprocessable, "checkin_closed", "check-in is closed for this flight")
+	ErrTicketCancelled    = apperr.New(apperr.ErrConflict, "ticket_cancelled", "ticket is cancelled")
+	ErrAlreadyCheckedIn   = apperr.New(apperr.ErrConflict, "already_checked_in", "ticket is already checked in")
+	ErrTicketNotCheckable = apperr.New(apperr.ErrConflict, "ticket_not_checkable", "ticket cannot be checked in in its current status")
+	ErrSeatRequired       = apperr.New(apperr.ErrValidation, "seat_required", "seat number is required for check-in")
+)
+
+// CheckInWindow describes when online check-in is available relative to departure.
+type CheckInWindow struct {
+	Opens  time.Duration // how long before departure check-in opens
+	Closes time.Duration // how long before departure check-in closes
+}
+
+// DefaultCheckInWindow opens check-in 24 hours before departure and closes it 1 hour before.
+var DefaultCheckInWindow = CheckInWindow{
+	Opens:  24 * time.Hour,
+	Closes: time.Hour,
+}
+
+// Validate checks that now is within the check-in window for departure.
+func (w CheckInWindow) Validate(departure, now time.Time) error {
+	if now.Before(departure.Add(-w.Opens)) {
+		return ErrCheckInNotOpen
+	}
+	if !now.Before(departure.Add(-w.Closes)) {
+		return ErrCheckInClosed
+	}
+	return nil
+}
+
+// CanCheckIn checks that ticket status allows check-in.
+func CanCheckIn(status Status) error {
+	switch {
+	case status == StatusCancelled:
+		return ErrTicketCancelled
+	case status == StatusCheckedIn:
+		return ErrAlreadyCheckedIn
+	case !status.CanTransition(StatusCheckedIn):
+		return ErrTicketNotCheckable
+	default:
+		return nil
+	}
+}
+
+// CheckInReq collects info about ticket for online check-in.
+type CheckInReq struct {
+	TicketID   string `json:"ticket_id"`
+	SeatNumber string `json:"seat_number"`
+}
@@ -0,0 +1,39 @@
+package booking
+
+import (
+	"testing"
+	"time"
+
+	"flightticketservice/pkg/apperr"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckInWindowValidate(t *testing.T) {
+	departure := time.Date(2024, 3, 16, 10, 0, 0, 0, time.UTC)
+	window := DefaultCheckInWindow
+
+	assert.ErrorIs(t, window.Validate(departure, departure.Add(-25*time.Hour)), ErrCheckInNotOpen)
+	assert.NoError(t, window.Validate(departure, departure.Add(-24*time.Hour)))
+	assert.NoError(t, window.Validate(departure, departure.Add(-2*time.Hour)))
+	assert.ErrorIs(t, window.Validate(departure, departure.Add(-time.Hour)), ErrCheckInClosed)
+	assert.ErrorIs(t, window.Validate(departure, departure.Add(time.Hour)), ErrCheckInClosed)
+}
+
+func TestCanCheckIn(t *testing.T) {
+	assert.NoError(t, CanCheckIn(StatusBooked))
+	assert.NoError(t, CanCheckIn(StatusConfirmed))
+	assert.ErrorIs(t, CanCheckIn(StatusCancelled), ErrTicketCancelled)
+	assert.ErrorIs(t, CanCheckIn(StatusCheckedIn), ErrAlreadyCheckedIn)
+	assert.ErrorIs(t, CanCheckIn(StatusHeld), ErrTicketNotCheckable)
+	assert.ErrorIs(t, CanCheckIn(StatusRefunded), ErrTicketNotCheckable)
+}
+
+func TestCheckInErrorCodes(t *testing.T) {
+	assert.Equal(t, "checkin_not_open", apperr.Code(ErrCheckInNotOpen))
+	assert.Equal(t, "checkin_closed", apperr.Code(ErrCheckInClosed))
+	assert.Equal(t, "ticket_cancelled", apperr.Code(ErrTicketCancelled))
+	assert.Equal(t, "seat_required", apperr.Code(ErrSeatRequired))
+	assert.ErrorIs(t, ErrCheckInClosed, apperr.ErrUnprocessable)
+	assert.ErrorIs(t, ErrAlreadyCheckedIn, apperr.ErrConflict)
+}
@@ -0,0 +1,45 @@
+package booking
+
+// Events keeps booking events of a unit of work, log records and metrics, until it ends,
+// so an attempt that is rolled back or retried reports no bookings that never happened.
+// Stores without Events report events at once.
+type Events struct {
+	events []event
+}
+
+type event struct {
+	report  func()
+	failure bool // failures are reported even if the unit of work fails
+}
+
+// Flush reports events of a unit of work that ended: all of them if it committed,
+// only failures if it did not. Flush of nil Events does nothing.
+func (e *Events) Flush(committed bool) {
+	if e == nil {
+		return
+	}
+	for _, ev := range e.events {
+		if committed || ev.failure {
+			ev.report()
+		}
+	}
+	e.events = nil
+}
+
+// done reports event of a change when the unit of work commits, nil Events report it at once.
+func (e *Events) done(report func()) {
+	if e == nil {
+		report()
+		return
+	}
+	e.events = append(e.events, event{report: report})
+}
+
+// failed reports event of a failure when the unit of work ends, nil Events report it at once.
+func (e *Events) failed(report func()) {
+	if e == nil {
+		report()
+		return
+	}
+	e.events = append(e.events, event{report: report, failure: true})
+}
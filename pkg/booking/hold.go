@@ -0,0 +1,161 @@
+package booking
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"flightticketservice/pkg/apperr"
+	"flightticketservice/pkg/database"
+	"flightticketservice/utils"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrHoldExpired is returned when held ticket is confirmed after its hold expired.
+var ErrHoldExpired = apperr.New(apperr.ErrGone, "hold_expired", "seat hold has expired")
+
+// DefaultHoldTTL is how long a seat is held when no TTL is configured.
+const DefaultHoldTTL = 15 * time.Minute
+
+// confirmHold checks that locked ticket can be booked at now.
+func (t *lockedTicket) confirmHold(now time.Time) error {
+	if err := Transition(t.status, StatusBooked); err != nil {
+		return err
+	}
+	if t.holdExpiresAt.Valid && !now.Before(t.holdExpiresAt.Time) {
+		return ErrHoldExpired
+	}
+	return nil
+}
+
+// HoldTicket holds a seat for the passenger until ttl passes
+// @Summary Hold a seat
+// @Description Creates a held ticket that must be confirmed before the hold expires.
+// @Tags booking
+// @Accept json
+// @Produce json
+// @Param hold body HoldTicketReq true "Hold data"
+// @Success 201 {object} Ticket
+// @Failure 400 "Invalid hold data"
+// @Failure 409 "Flight is sold out or seat is taken"
+// @Router /api/v1/tickets/hold [post]
+func (bs *BookingStore) HoldTicket(ctx context.Context, ticket *Ticket, ttl time.Duration) (err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.HoldTicket", trace.WithAttributes(utils.FlightIDKey.String(ticket.FlightID)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	if ttl <= 0 {
+		return errors.New("hold TTL must be positive")
+	}
+
+	expiresAt := time.Now().UTC().Add(ttl)
+	ticket.Status = StatusHeld
+	ticket.HoldExpiresAt = &expiresAt
+
+	return bs.CreateTicket(ctx, ticket)
+}
+
+// ConfirmHold books held ticket if its hold has not expired
+// @Summary Confirm a held seat
+// @Description Books the held ticket. Fails if the hold has already expired.
+// @Tags booking
+// @Accept json
+// @Produce json
+// @Param id path string true "Unique identifier of the ticket"
+// @Success 200 "Ticket successfully booked"
+// @Failure 404 "Ticket not found"
+// @Failure 409 "Ticket is not held"
+// @Failure 410 "Seat hold has expired"
+// @Router /api/v1/tickets/{id}/confirm [post]
+func (bs *BookingStore) ConfirmHold(ctx context.Context, ticketID string) (err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.ConfirmHold", trace.WithAttributes(utils.TicketIDKey.String(ticketID)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	query := `update booking_flights set status = $1, hold_expires_at = NULL where id = $2`
+
+	err = database.RunInTx(ctx, bs.db, func(tx *sql.Tx) error {
+		locked, err := lockTicket(ctx, tx, ticketID)
+		if err != nil {
+			return err
+		}
+
+		if err := locked.confirmHold(time.Now().UTC()); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, StatusBooked, ticketID)
+		return err
+	})
+	if err != nil {
+		bs.events.failed(func() { bs.metrics.failed(err) })
+		return err
+	}
+
+	bs.events.done(func() {
+		bs.logger.InfoContext(ctx, "hold confirmed", "ticket_id", ticketID)
+		bs.metrics.booked(kindTicket, 1)
+	})
+	return nil
+}
+
+// ReleaseExpiredHolds cancels held tickets whose hold has expired and frees their seats.
+func (bs *BookingStore) ReleaseExpiredHolds(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.ReleaseExpiredHolds")
+	defer func() { utils.EndSpan(span, err) }()
+
+	query := `update booking_flights set status = $1
+	where status = $2 and hold_expires_at <= $3`
+
+	res, err := bs.db.ExecContext(ctx, query, StatusCancelled, StatusHeld, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	released, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	utils.SetRows(ctx, released)
+
+	bs.events.done(func() {
+		bs.metrics.cancelled(kindHoldExpired, int(released))
+	})
+	return released, nil
+}
+
+// HoldReaper periodically releases expired seat holds.
+type HoldReaper struct {
+	tickets  BookingService
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewHoldReaper creates reaper that checks holds every interval.
+func NewHoldReaper(tickets BookingService, interval time.Duration, logger *slog.Logger) *HoldReaper {
+	return &HoldReaper{tickets: tickets, interval: interval, logger: logger}
+}
+
+// Run releases expired holds every interval until ctx is cancelled.
+func (r *HoldReaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.InfoContext(ctx, "hold reaper stopped")
+			return
+		case <-ticker.C:
+			released, err := r.tickets.ReleaseExpiredHolds(ctx)
+			if err != nil {
+				r.logger.ErrorContext(ctx, "failed to release expired holds", "error", err)
+				continue
+			}
+			if released > 0 {
+				r.logger.InfoContext(ctx, "released expired holds", "count", released)
+			}
+		}
+	}
+}
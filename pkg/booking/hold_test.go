@@ -0,0 +1,26 @@
+package booking
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockedTicketConfirmHold(t *testing.T) {
+	now := time.Date(2024, 3, 16, 10, 0, 0, 0, time.UTC)
+
+	held := &lockedTicket{
+		status:        StatusHeld,
+		holdExpiresAt: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+	}
+	assert.NoError(t, held.confirmHold(now))
+	assert.ErrorIs(t, held.confirmHold(now.Add(time.Minute)), ErrHoldExpired)
+
+	withoutExpiry := &lockedTicket{status: StatusHeld}
+	assert.NoError(t, withoutExpiry.confirmHold(now))
+
+	cancelled := &lockedTicket{status: StatusCancelled}
+	assert.ErrorIs(t, cancelled.confirmHold(now), ErrInvalidTransition)
+}
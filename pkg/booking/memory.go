@@ -0,0 +1,783 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flightticketservice/pkg/database"
+	"flightticketservice/pkg/flights"
+	"flightticketservice/pkg/pagination"
+	"flightticketservice/pkg/passenger"
+)
+
+// MemoryStore keeps tickets and booking records in memory, it implements BookingService without a database.
+// Flights and passengers are read from their services like BookingStore reads their tables.
+type MemoryStore struct {
+	*memoryData
+	writes database.WriteLock
+	events *Events // nil reports booking events at once
+}
+
+// memoryData is state of a store shared with its views.
+type memoryData struct {
+	mu         sync.RWMutex
+	lastID     int
+	tickets    map[string]*Ticket
+	records    map[string]time.Time // creation time by locator
+	flights    flights.FlightService
+	passengers passenger.Storage
+	checkIn    CheckInWindow
+	logger     *slog.Logger
+	metrics    *Metrics
+}
+
+// NewMemoryStore creates empty in-memory ticket store,
+// booking events are logged to logger and counted by metrics.
+func NewMemoryStore(
+	flights flights.FlightService,
+	passengers passenger.Storage,
+	logger *slog.Logger,
+	metrics *Metrics,
+) *MemoryStore {
+	return &MemoryStore{memoryData: &memoryData{
+		tickets:    map[string]*Ticket{},
+		records:    map[string]time.Time{},
+		flights:    flights,
+		passengers: passengers,
+		checkIn:    DefaultCheckInWindow,
+		logger:     logger,
+		metrics:    metrics,
+	}}
+}
+
+// WithWriteLock returns view of the store sharing its tickets that locks writes with lock.
+func (ms *MemoryStore) WithWriteLock(lock database.WriteLock) *MemoryStore {
+	view := *ms
+	view.writes = lock
+	return &view
+}
+
+// WithEvents returns view of the store sharing its tickets that keeps booking events in events
+// until its unit of work ends.
+func (ms *MemoryStore) WithEvents(events *Events) *MemoryStore {
+	view := *ms
+	view.events = events
+	return &view
+}
+
+// Occupancy returns number of active tickets and seats they hold on the flight,
+// it is flights.Occupancy of the in-memory flight store.
+func (ms *MemoryStore) Occupancy(flightID string) (int, []string) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	booked, seats := 0, []string{}
+	for _, ticket := range ms.tickets {
+		if ticket.FlightID != flightID || !active(ticket.Status) {
+			continue
+		}
+		booked++
+		if ticket.SeatNumber != "" {
+			seats = append(seats, ticket.SeatNumber)
+		}
+	}
+	return booked, seats
+}
+
+// flight returns the flight by id. It is called before mu is locked,
+// because the flight store reads availability back from Occupancy.
+func (ms *MemoryStore) flight(ctx context.Context, flightID string) (*flights.Flight, error) {
+	flight, err := ms.flights.GetFlightByID(ctx, flightID)
+	if err != nil {
+		if errors.Is(err, flights.ErrFlightNotFound) {
+			return nil, ErrFlightNotFound
+		}
+		return nil, err
+	}
+	return flight, nil
+}
+
+// capacity returns capacity of the flight, like flight it is called before mu is locked.
+func (ms *MemoryStore) capacity(ctx context.Context, flightID string) (int, error) {
+	flight, err := ms.flight(ctx, flightID)
+	if err != nil {
+		return 0, err
+	}
+	return flight.Capacity, nil
+}
+
+// reserveSeat checks that flight has room for one more active ticket besides ticketID, mu must be held.
+func (ms *MemoryStore) reserveSeat(capacity int, flightID, ticketID string) error {
+	booked := 0
+	for id, ticket := range ms.tickets {
+		if id != ticketID && ticket.FlightID == flightID && active(ticket.Status) {
+			booked++
+		}
+	}
+
+	if booked >= capacity {
+		return ErrSoldOut
+	}
+	return nil
+}
+
+// checkSeat enforces one active ticket per seat like booking_flights_active_seat_idx, mu must be held.
+func (ms *MemoryStore) checkSeat(ticket *Ticket) error {
+	if ticket.SeatNumber == "" || !active(ticket.Status) {
+		return nil
+	}
+
+	for id, other := range ms.tickets {
+		if id != ticket.ID && other.FlightID == ticket.FlightID &&
+			other.SeatNumber == ticket.SeatNumber && active(other.Status) {
+			return ErrSeatTaken
+		}
+	}
+	return nil
+}
+
+// ticket returns stored ticket by id, mu must be held.
+func (ms *MemoryStore) ticket(id string) (*Ticket, error) {
+	ticket, ok := ms.tickets[id]
+	if !ok {
+		return nil, ErrTicketNotFound
+	}
+	return ticket, nil
+}
+
+// insert checks and stores a copy of the ticket, setting its ID, mu must be held.
+func (ms *MemoryStore) insert(ticket *Ticket, capacity int) error {
+	if err := Transition(statusNone, ticket.Status); err != nil {
+		return err
+	}
+
+	if err := ms.reserveSeat(capacity, ticket.FlightID, ""); err != nil {
+		return err
+	}
+
+	stored := cloneTicket(ticket)
+	stored.ID = strconv.Itoa(ms.lastID + 1)
+	if err := ms.checkSeat(stored); err != nil {
+		return err
+	}
+
+	ms.lastID++
+	ms.tickets[stored.ID] = stored
+	ticket.ID = stored.ID
+	return nil
+}
+
+// CreateTicket stores ticket and sets its ID
+func (ms *MemoryStore) CreateTicket(ctx context.Context, ticket *Ticket) error {
+	defer ms.writes.Lock()()
+
+	capacity, err := ms.capacity(ctx, ticket.FlightID)
+	if err != nil {
+		ms.events.failed(func() { ms.metrics.failed(err) })
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ms.insert(ticket, capacity); err != nil {
+		ms.events.failed(func() { ms.metrics.failed(err) })
+		return err
+	}
+
+	ms.events.done(func() {
+		logTicketCreated(ctx, ms.logger, ticket)
+		if ticket.Status == StatusBooked {
+			ms.metrics.booked(kindTicket, 1)
+		}
+	})
+	return nil
+}
+
+// BookTicket books held ticket on the flight
+func (ms *MemoryStore) BookTicket(ctx context.Context, id, flightID, passengerID, additionalInfo string) error {
+	defer ms.writes.Lock()()
+
+	if id == "" {
+		return errors.New("ticket ID cannot be empty")
+	}
+
+	if err := ms.bookTicket(ctx, id, flightID, passengerID, additionalInfo); err != nil {
+		ms.events.failed(func() { ms.metrics.failed(err) })
+		return err
+	}
+
+	ms.events.done(func() {
+		ms.logger.InfoContext(ctx, "ticket booked", "ticket_id", id, "flight_id", flightID, "passenger_id", passengerID)
+		ms.metrics.booked(kindTicket, 1)
+	})
+	return nil
+}
+
+// bookTicket moves held ticket to booked status on the flight.
+func (ms *MemoryStore) bookTicket(ctx context.Context, id, flightID, passengerID, additionalInfo string) error {
+	capacity, err := ms.capacity(ctx, flightID)
+	if err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ticket, err := ms.ticket(id)
+	if err != nil {
+		return err
+	}
+
+	if err := confirmHold(ticket, time.Now().UTC()); err != nil {
+		return err
+	}
+	if ticket.PassengerID != passengerID {
+		return ErrNotTicketOwner
+	}
+	if ticket.FlightID != flightID {
+		return ErrFlightMismatch
+	}
+
+	if err := ms.reserveSeat(capacity, flightID, id); err != nil {
+		return err
+	}
+
+	updated := cloneTicket(ticket)
+	updated.Status = StatusBooked
+	updated.AdditionalInfo = additionalInfo
+	updated.HoldExpiresAt = nil
+	if err := ms.checkSeat(updated); err != nil {
+		return err
+	}
+
+	ms.tickets[id] = updated
+	return nil
+}
+
+// CancelTicket cancels an existing ticket
+func (ms *MemoryStore) CancelTicket(ctx context.Context, ticketID string) error {
+	defer ms.writes.Lock()()
+
+	if ticketID == "" {
+		return errors.New("ticket ID cannot be empty")
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ticket, err := ms.ticket(ticketID)
+	if err != nil {
+		return err
+	}
+
+	if err := Transition(ticket.Status, StatusCancelled); err != nil {
+		return err
+	}
+
+	ticket.Status = StatusCancelled
+	ms.events.done(func() {
+		ms.logger.InfoContext(ctx, "ticket cancelled", "ticket_id", ticketID)
+		ms.metrics.cancelled(kindTicket, 1)
+	})
+	return nil
+}
+
+// ChangeFlight moves ticket to another flight
+func (ms *MemoryStore) ChangeFlight(ctx context.Context, ticketID string, newFlightID string) error {
+	defer ms.writes.Lock()()
+
+	if ticketID == "" || newFlightID == "" {
+		return errors.New("ticket ID and new flight ID cannot be empty")
+	}
+
+	flight, err := ms.flight(ctx, newFlightID)
+	if err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ticket, err := ms.ticket(ticketID)
+	if err != nil {
+		return err
+	}
+
+	if !ticket.Status.CanChangeFlight() {
+		return fmt.Errorf("%w: cannot change flight of %s ticket", ErrInvalidTransition, ticket.Status)
+	}
+
+	if err := ms.reserveSeat(flight.Capacity, newFlightID, ticketID); err != nil {
+		return err
+	}
+
+	updated := cloneTicket(ticket)
+	updated.FlightID = newFlightID
+	updated.DepartureTime, updated.ArrivalTime = flight.Departure, flight.Arrival
+	if err := ms.checkSeat(updated); err != nil {
+		return err
+	}
+
+	ms.tickets[ticketID] = updated
+	ms.events.done(func() {
+		ms.logger.InfoContext(ctx, "ticket flight changed", "ticket_id", ticketID, "flight_id", newFlightID)
+		ms.metrics.flightChanged()
+	})
+	return nil
+}
+
+// GetTicketByID returns ticket by id
+func (ms *MemoryStore) GetTicketByID(ctx context.Context, ticketID string) (*Ticket, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	ticket, err := ms.ticket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return cloneTicket(ticket), nil
+}
+
+// GetTickets returns page of tickets
+func (ms *MemoryStore) GetTickets(ctx context.Context, params pagination.Params) (*pagination.Page[*Ticket], error) {
+	ms.mu.RLock()
+	tickets := make([]*Ticket, 0, len(ms.tickets))
+	for _, ticket := range ms.tickets {
+		tickets = append(tickets, cloneTicket(ticket))
+	}
+	ms.mu.RUnlock()
+
+	return pagination.Slice(listTickets, params, tickets, ticketField)
+}
+
+// UpdateTicket updates the details of an existing ticket
+func (ms *MemoryStore) UpdateTicket(ctx context.Context, id string, newTicket *Ticket) error {
+	defer ms.writes.Lock()()
+
+	if newTicket == nil {
+		return errors.New("ticket cannot be nil")
+	}
+
+	capacity, capacityErr := ms.capacity(ctx, newTicket.FlightID)
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ticket, err := ms.ticket(id)
+	if err != nil {
+		return err
+	}
+
+	newStatus := newTicket.Status
+	if newStatus == statusNone {
+		newStatus = ticket.Status
+	} else if err := Transition(ticket.Status, newStatus); err != nil {
+		return err
+	}
+
+	if newTicket.FlightID != ticket.FlightID {
+		if !ticket.Status.CanChangeFlight() {
+			return fmt.Errorf("%w: cannot change flight of %s ticket", ErrInvalidTransition, ticket.Status)
+		}
+		if capacityErr != nil {
+			return capacityErr
+		}
+		if err := ms.reserveSeat(capacity, newTicket.FlightID, id); err != nil {
+			return err
+		}
+	}
+
+	updated := cloneTicket(ticket)
+	updated.FlightID = newTicket.FlightID
+	updated.PassengerID = newTicket.PassengerID
+	updated.BookingTime = newTicket.BookingTime
+	updated.DepartureTime = newTicket.DepartureTime
+	updated.ArrivalTime = newTicket.ArrivalTime
+	updated.Status = newStatus
+	updated.SeatNumber = newTicket.SeatNumber
+	updated.AdditionalInfo = newTicket.AdditionalInfo
+	if err := ms.checkSeat(updated); err != nil {
+		return err
+	}
+
+	ms.tickets[id] = updated
+	ms.events.done(func() {
+		ms.logger.InfoContext(ctx, "ticket updated", "ticket_id", id, "flight_id", newTicket.FlightID, "status", newStatus)
+	})
+	return nil
+}
+
+// DeleteTicket deletes ticket by id
+func (ms *MemoryStore) DeleteTicket(ctx context.Context, ticketID string) error {
+	defer ms.writes.Lock()()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, err := ms.ticket(ticketID); err != nil {
+		return err
+	}
+
+	delete(ms.tickets, ticketID)
+	ms.events.done(func() {
+		ms.logger.InfoContext(ctx, "ticket deleted", "ticket_id", ticketID)
+	})
+	return nil
+}
+
+// CheckIn checks passenger in for the flight online
+func (ms *MemoryStore) CheckIn(ctx context.Context, ticketID, seatNumber string, departure time.Time) (*Ticket, error) {
+	defer ms.writes.Lock()()
+
+	ticket, err := ms.GetTicketByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CanCheckIn(ticket.Status); err != nil {
+		return nil, err
+	}
+
+	if err := ms.checkIn.Validate(departure, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	if seatNumber == "" {
+		seatNumber = ticket.SeatNumber
+	}
+	if seatNumber == "" {
+		return nil, ErrSeatRequired
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stored, err := ms.ticket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CanCheckIn(stored.Status); err != nil {
+		return nil, err
+	}
+
+	updated := cloneTicket(stored)
+	updated.Status = StatusCheckedIn
+	updated.SeatNumber = seatNumber
+	if err := ms.checkSeat(updated); err != nil {
+		return nil, err
+	}
+
+	ms.tickets[ticketID] = updated
+	ms.events.done(func() {
+		ms.logger.InfoContext(ctx, "ticket checked in", "ticket_id", ticketID, "seat_number", seatNumber)
+	})
+	return cloneTicket(updated), nil
+}
+
+// GetTakenSeats returns seats held by active tickets on the flight
+func (ms *MemoryStore) GetTakenSeats(ctx context.Context, flightID string) ([]string, error) {
+	_, seats := ms.Occupancy(flightID)
+	return seats, nil
+}
+
+// HoldTicket holds a seat for the passenger until ttl passes
+func (ms *MemoryStore) HoldTicket(ctx context.Context, ticket *Ticket, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("hold TTL must be positive")
+	}
+
+	expiresAt := time.Now().UTC().Add(ttl)
+	ticket.Status = StatusHeld
+	ticket.HoldExpiresAt = &expiresAt
+
+	return ms.CreateTicket(ctx, ticket)
+}
+
+// ConfirmHold books held ticket if its hold has not expired
+func (ms *MemoryStore) ConfirmHold(ctx context.Context, ticketID string) error {
+	defer ms.writes.Lock()()
+
+	if err := ms.confirm(ticketID); err != nil {
+		ms.events.failed(func() { ms.metrics.failed(err) })
+		return err
+	}
+
+	ms.events.done(func() {
+		ms.logger.InfoContext(ctx, "hold confirmed", "ticket_id", ticketID)
+		ms.metrics.booked(kindTicket, 1)
+	})
+	return nil
+}
+
+// confirm books held ticket if its hold has not expired.
+func (ms *MemoryStore) confirm(ticketID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ticket, err := ms.ticket(ticketID)
+	if err != nil {
+		return err
+	}
+
+	if err := confirmHold(ticket, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	ticket.Status = StatusBooked
+	ticket.HoldExpiresAt = nil
+	return nil
+}
+
+// ReleaseExpiredHolds cancels held tickets whose hold has expired and frees their seats.
+func (ms *MemoryStore) ReleaseExpiredHolds(ctx context.Context) (int64, error) {
+	defer ms.writes.Lock()()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now().UTC()
+	var released int64
+	for _, ticket := range ms.tickets {
+		if ticket.Status == StatusHeld && ticket.HoldExpiresAt != nil && !ticket.HoldExpiresAt.After(now) {
+			ticket.Status = StatusCancelled
+			released++
+		}
+	}
+
+	ms.events.done(func() {
+		ms.metrics.cancelled(kindHoldExpired, int(released))
+	})
+	return released, nil
+}
+
+// SeatsRemaining returns number of seats not taken by active tickets by ID of flight that has not departed yet.
+func (ms *MemoryStore) SeatsRemaining(ctx context.Context) (map[string]int, error) {
+	now := time.Now().UTC()
+	remaining := map[string]int{}
+
+	params := pagination.Params{Limit: pagination.MaxLimit}
+	for {
+		page, err := ms.flights.GetFlights(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, flight := range page.Items {
+			if !flight.Departure.After(now) {
+				continue
+			}
+			booked, _ := ms.Occupancy(flight.ID)
+			remaining[flight.ID] = flight.Capacity - booked
+		}
+
+		if page.NextCursor == "" {
+			return remaining, nil
+		}
+		params.Cursor = page.NextCursor
+	}
+}
+
+// CreateBooking creates booking record with all tickets or none of them
+func (ms *MemoryStore) CreateBooking(ctx context.Context, tickets []*Ticket) (*BookingRecord, error) {
+	defer ms.writes.Lock()()
+
+	if len(tickets) == 0 {
+		return nil, errors.New("booking must have at least one ticket")
+	}
+
+	record, err := ms.createBooking(ctx, tickets)
+	if err != nil {
+		ms.events.failed(func() { ms.metrics.failed(err) })
+		return nil, err
+	}
+
+	ms.events.done(func() {
+		logBookingCreated(ctx, ms.logger, record)
+		ms.metrics.booked(kindRecord, len(record.Tickets))
+	})
+	return record, nil
+}
+
+// createBooking inserts booking record with a new locator and its tickets.
+func (ms *MemoryStore) createBooking(ctx context.Context, tickets []*Ticket) (*BookingRecord, error) {
+	capacities := map[string]int{}
+	for _, ticket := range tickets {
+		if _, ok := capacities[ticket.FlightID]; ok {
+			continue
+		}
+		capacity, err := ms.capacity(ctx, ticket.FlightID)
+		if err != nil {
+			return nil, err
+		}
+		capacities[ticket.FlightID] = capacity
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	locator, err := ms.newLocator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &BookingRecord{Locator: locator, CreatedAt: time.Now().UTC(), Tickets: tickets}
+	for i, ticket := range tickets {
+		ticket.Locator = locator
+		if err := ms.insert(ticket, capacities[ticket.FlightID]); err != nil {
+			// roll back tickets inserted so far
+			for _, inserted := range tickets[:i] {
+				delete(ms.tickets, inserted.ID)
+			}
+			for _, ticket := range tickets {
+				ticket.ID, ticket.Locator = "", ""
+			}
+			return nil, err
+		}
+	}
+
+	ms.records[locator] = record.CreatedAt
+	return record, nil
+}
+
+// newLocator generates locator that is not used yet, mu must be held.
+func (ms *MemoryStore) newLocator(ctx context.Context) (string, error) {
+	for attempt := 1; ; attempt++ {
+		locator, err := NewLocator()
+		if err != nil {
+			return "", err
+		}
+		if _, ok := ms.records[locator]; !ok {
+			return locator, nil
+		}
+		if attempt == locatorAttempts {
+			return "", errors.New("cannot generate unique booking locator")
+		}
+	}
+}
+
+// GetBooking returns booking record if one of its passengers has the last name
+func (ms *MemoryStore) GetBooking(ctx context.Context, locator, lastName string) (*BookingRecord, error) {
+	ms.mu.RLock()
+	createdAt, ok := ms.records[locator]
+	record := &BookingRecord{Locator: locator, CreatedAt: createdAt}
+	for _, ticket := range ms.tickets {
+		if ticket.Locator == locator {
+			record.Tickets = append(record.Tickets, cloneTicket(ticket))
+		}
+	}
+	ms.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrBookingNotFound
+	}
+
+	for _, ticket := range record.Tickets {
+		passenger, err := ms.passengers.GetPassengerByID(ctx, ticket.PassengerID)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(passenger.LastName, lastName) {
+			sort.Slice(record.Tickets, func(i, j int) bool {
+				a, _ := strconv.Atoi(record.Tickets[i].ID)
+				b, _ := strconv.Atoi(record.Tickets[j].ID)
+				return a < b
+			})
+			return record, nil
+		}
+	}
+
+	return nil, ErrBookingNotFound
+}
+
+// CancelBooking cancels all active tickets of the booking record at once
+func (ms *MemoryStore) CancelBooking(ctx context.Context, locator, lastName string) error {
+	defer ms.writes.Lock()()
+
+	if _, err := ms.GetBooking(ctx, locator, lastName); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	cancel := []*Ticket{}
+	for _, ticket := range ms.tickets {
+		if ticket.Locator != locator || ticket.Status == StatusCancelled || ticket.Status == StatusRefunded {
+			continue
+		}
+		if err := Transition(ticket.Status, StatusCancelled); err != nil {
+			return err
+		}
+		cancel = append(cancel, ticket)
+	}
+
+	if len(cancel) == 0 {
+		return &TransitionError{From: StatusCancelled, To: StatusCancelled}
+	}
+
+	for _, ticket := range cancel {
+		ticket.Status = StatusCancelled
+	}
+	ms.events.done(func() {
+		ms.logger.InfoContext(ctx, "booking cancelled", "locator", locator)
+		ms.metrics.cancelled(kindRecord, len(cancel))
+	})
+	return nil
+}
+
+// confirmHold checks that ticket can be booked at now, like lockedTicket.confirmHold.
+func confirmHold(ticket *Ticket, now time.Time) error {
+	locked := &lockedTicket{status: ticket.Status}
+	if ticket.HoldExpiresAt != nil {
+		locked.holdExpiresAt.Time, locked.holdExpiresAt.Valid = *ticket.HoldExpiresAt, true
+	}
+	return locked.confirmHold(now)
+}
+
+// active reports if ticket in the status holds a seat on the flight.
+func active(status Status) bool {
+	switch status {
+	case StatusCancelled, StatusRefunded, StatusNoShow:
+		return false
+	}
+	return true
+}
+
+// cloneTicket copies ticket so that stored tickets are not shared with callers.
+func cloneTicket(ticket *Ticket) *Ticket {
+	copied := *ticket
+	if ticket.HoldExpiresAt != nil {
+		expiresAt := *ticket.HoldExpiresAt
+		copied.HoldExpiresAt = &expiresAt
+	}
+	return &copied
+}
+
+// Snapshot copies tickets and booking records of the store, the returned func puts the copy back.
+// IDs given out after the snapshot are not reused, like values of a sequence after a rollback.
+func (ms *MemoryStore) Snapshot() (restore func()) {
+	ms.mu.RLock()
+	tickets := make(map[string]*Ticket, len(ms.tickets))
+	for id, ticket := range ms.tickets {
+		tickets[id] = cloneTicket(ticket)
+	}
+	records := maps.Clone(ms.records)
+	ms.mu.RUnlock()
+
+	return func() {
+		ms.mu.Lock()
+		ms.tickets = tickets
+		ms.records = records
+		ms.mu.Unlock()
+	}
+}
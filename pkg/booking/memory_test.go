@@ -0,0 +1,130 @@
+package booking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flightticketservice/pkg/flights"
+	"flightticketservice/pkg/pagination"
+	"flightticketservice/pkg/passenger"
+	"flightticketservice/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMemoryStore(t *testing.T) (*MemoryStore, *flights.Flight, *passenger.Passenger) {
+	ctx := context.Background()
+	passengers := passenger.NewMemoryStore()
+	flightStore := flights.NewMemoryStore()
+	store := NewMemoryStore(flightStore, passengers, utils.DiscardLogger(), NewMetrics(prometheus.NewRegistry()))
+	flightStore.SetOccupancy(store.Occupancy)
+
+	departure := time.Now().UTC().Add(48 * time.Hour)
+	flight := flights.NewFlight("Aeroflot", "MOW", "PAR", departure, departure.Add(4*time.Hour), 300)
+	flight.Capacity = 2
+	assert.NoError(t, flightStore.CreateFlight(ctx, flight))
+
+	john := &passenger.Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	assert.NoError(t, passengers.CreatePassenger(ctx, john))
+
+	return store, flight, john
+}
+
+func TestMemoryStoreCapacityAndSeats(t *testing.T) {
+	ctx := context.Background()
+	store, flight, john := newTestMemoryStore(t)
+	newTicket := func(seat string) *Ticket {
+		return CreateNewTicket(flight.ID, john.ID, StatusBooked, seat, "", flight.Departure, flight.Arrival)
+	}
+
+	first := newTicket("1A")
+	assert.NoError(t, store.CreateTicket(ctx, first))
+	assert.ErrorIs(t, store.CreateTicket(ctx, newTicket("1A")), ErrSeatTaken)
+	assert.NoError(t, store.CreateTicket(ctx, newTicket("1C")))
+	assert.ErrorIs(t, store.CreateTicket(ctx, newTicket("2A")), ErrSoldOut)
+
+	assert.NoError(t, store.CancelTicket(ctx, first.ID))
+	assert.ErrorIs(t, store.CancelTicket(ctx, first.ID), ErrInvalidTransition)
+	assert.NoError(t, store.CreateTicket(ctx, newTicket("1A")))
+
+	assert.ErrorIs(t, store.CreateTicket(ctx, CreateNewTicket("42", john.ID, StatusBooked, "", "", flight.Departure, flight.Arrival)), ErrFlightNotFound)
+
+	_, err := store.GetTicketByID(ctx, "42")
+	assert.ErrorIs(t, err, ErrTicketNotFound)
+	assert.ErrorIs(t, store.DeleteTicket(ctx, "42"), ErrTicketNotFound)
+}
+
+func TestMemoryStoreHolds(t *testing.T) {
+	ctx := context.Background()
+	store, flight, john := newTestMemoryStore(t)
+
+	held := CreateNewTicket(flight.ID, john.ID, "", "1A", "", flight.Departure, flight.Arrival)
+	assert.NoError(t, store.HoldTicket(ctx, held, time.Hour))
+	assert.NoError(t, store.ConfirmHold(ctx, held.ID))
+	assert.ErrorIs(t, store.ConfirmHold(ctx, held.ID), ErrInvalidTransition)
+
+	expired := CreateNewTicket(flight.ID, john.ID, "", "1C", "", flight.Departure, flight.Arrival)
+	assert.NoError(t, store.HoldTicket(ctx, expired, time.Hour))
+	past := time.Now().UTC().Add(-time.Minute)
+	store.tickets[expired.ID].HoldExpiresAt = &past
+
+	assert.ErrorIs(t, store.ConfirmHold(ctx, expired.ID), ErrHoldExpired)
+
+	released, err := store.ReleaseExpiredHolds(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), released)
+
+	seats, err := store.GetTakenSeats(ctx, flight.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1A"}, seats)
+}
+
+func TestMemoryStoreBookTicket(t *testing.T) {
+	ctx := context.Background()
+	store, flight, john := newTestMemoryStore(t)
+
+	held := CreateNewTicket(flight.ID, john.ID, "", "1A", "", flight.Departure, flight.Arrival)
+	assert.NoError(t, store.HoldTicket(ctx, held, time.Hour))
+
+	assert.ErrorIs(t, store.BookTicket(ctx, held.ID, flight.ID, "someone else", ""), ErrNotTicketOwner)
+	other := flights.NewFlight("Aeroflot", "MOW", "LON", flight.Departure, flight.Arrival, 300)
+	assert.NoError(t, store.flights.CreateFlight(ctx, other))
+	assert.ErrorIs(t, store.BookTicket(ctx, held.ID, other.ID, john.ID, ""), ErrFlightMismatch)
+	assert.NoError(t, store.BookTicket(ctx, held.ID, flight.ID, john.ID, "window"))
+
+	booked, err := store.GetTicketByID(ctx, held.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusBooked, booked.Status)
+	assert.Equal(t, "1A", booked.SeatNumber)
+	assert.Nil(t, booked.HoldExpiresAt)
+}
+
+func TestMemoryStoreBooking(t *testing.T) {
+	ctx := context.Background()
+	store, flight, john := newTestMemoryStore(t)
+	newTicket := func(seat string) *Ticket {
+		return CreateNewTicket(flight.ID, john.ID, StatusBooked, seat, "", flight.Departure, flight.Arrival)
+	}
+
+	_, err := store.CreateBooking(ctx, []*Ticket{newTicket("1A"), newTicket("1A")})
+	assert.ErrorIs(t, err, ErrSeatTaken)
+	page, err := store.GetTickets(ctx, pagination.Params{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, page.Total)
+
+	record, err := store.CreateBooking(ctx, []*Ticket{newTicket("1A"), newTicket("1C")})
+	assert.NoError(t, err)
+	assert.Len(t, record.Locator, locatorLength)
+
+	found, err := store.GetBooking(ctx, record.Locator, "doe")
+	assert.NoError(t, err)
+	assert.Len(t, found.Tickets, 2)
+
+	_, err = store.GetBooking(ctx, record.Locator, "Roe")
+	assert.ErrorIs(t, err, ErrBookingNotFound)
+
+	assert.NoError(t, store.CancelBooking(ctx, record.Locator, "Doe"))
+	assert.ErrorIs(t, store.CancelBooking(ctx, record.Locator, "Doe"), ErrInvalidTransition)
+}
@@ -0,0 +1,139 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsNamespace prefixes names of service metrics.
+const metricsNamespace = "flightticket"
+
+// seatsCollectTimeout bounds the query of remaining seats made on each scrape.
+const seatsCollectTimeout = 5 * time.Second
+
+// Kinds of booked and cancelled tickets in metric labels.
+const (
+	kindTicket      = "ticket"       // single ticket booked or cancelled
+	kindRecord      = "record"       // tickets of a booking record booked or cancelled together
+	kindHoldExpired = "hold_expired" // held ticket released by the reaper
+)
+
+// Metrics counts booking events, it is shared by stores of all units of work.
+type Metrics struct {
+	bookingsCreated *prometheus.CounterVec
+	cancellations   *prometheus.CounterVec
+	flightChanges   prometheus.Counter
+	failedBookings  *prometheus.CounterVec
+}
+
+// NewMetrics creates booking metrics and registers them with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		bookingsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "bookings_created_total",
+			Help:      "Tickets booked or confirmed, by kind: ticket or record.",
+		}, []string{"kind"}),
+		cancellations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cancellations_total",
+			Help:      "Tickets cancelled, by kind: ticket, record or hold_expired.",
+		}, []string{"kind"}),
+		flightChanges: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "flight_changes_total",
+			Help:      "Tickets moved to another flight.",
+		}),
+		failedBookings: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "failed_bookings_total",
+			Help:      "Booking, hold and confirmation attempts that failed, by reason.",
+		}, []string{"reason"}),
+	}
+	reg.MustRegister(m.bookingsCreated, m.cancellations, m.flightChanges, m.failedBookings)
+
+	return m
+}
+
+func (m *Metrics) booked(kind string, tickets int) {
+	m.bookingsCreated.WithLabelValues(kind).Add(float64(tickets))
+}
+
+func (m *Metrics) cancelled(kind string, tickets int) {
+	m.cancellations.WithLabelValues(kind).Add(float64(tickets))
+}
+
+func (m *Metrics) flightChanged() {
+	m.flightChanges.Inc()
+}
+
+func (m *Metrics) failed(err error) {
+	m.failedBookings.WithLabelValues(failureReason(err)).Inc()
+}
+
+// failureReason returns reason label of a failed booking.
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrSoldOut):
+		return "sold_out"
+	case errors.Is(err, ErrSeatTaken):
+		return "seat_taken"
+	case errors.Is(err, ErrHoldExpired):
+		return "hold_expired"
+	case errors.Is(err, ErrInvalidTransition):
+		return "invalid_transition"
+	case errors.Is(err, ErrFlightNotFound):
+		return "flight_not_found"
+	case errors.Is(err, ErrTicketNotFound):
+		return "ticket_not_found"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	}
+	return "error"
+}
+
+// SeatsCollector reports seats remaining on upcoming flights, they are counted on each scrape.
+type SeatsCollector struct {
+	tickets BookingService
+	logger  *slog.Logger
+	desc    *prometheus.Desc
+}
+
+// NewSeatsCollector creates collector of seats remaining on flights of the ticket store.
+func NewSeatsCollector(tickets BookingService, logger *slog.Logger) *SeatsCollector {
+	return &SeatsCollector{
+		tickets: tickets,
+		logger:  logger,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "seats_remaining"),
+			"Seats not taken by active tickets on flights that have not departed yet.",
+			[]string{"flight_id"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *SeatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *SeatsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), seatsCollectTimeout)
+	defer cancel()
+
+	remaining, err := c.tickets.SeatsRemaining(ctx)
+	if err != nil {
+		c.logger.Error("failed to collect remaining seats", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for flightID, seats := range remaining {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(seats), flightID)
+	}
+}
@@ -0,0 +1,70 @@
+package booking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreMetrics(t *testing.T) {
+	ctx := context.Background()
+	store, flight, john := newTestMemoryStore(t)
+	newTicket := func(seat string) *Ticket {
+		return CreateNewTicket(flight.ID, john.ID, StatusBooked, seat, "", flight.Departure, flight.Arrival)
+	}
+
+	first := newTicket("1A")
+	assert.NoError(t, store.CreateTicket(ctx, first))
+	assert.ErrorIs(t, store.CreateTicket(ctx, newTicket("1A")), ErrSeatTaken)
+	_, err := store.CreateBooking(ctx, []*Ticket{newTicket("1C")})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, store.CreateTicket(ctx, newTicket("2A")), ErrSoldOut)
+	assert.NoError(t, store.CancelTicket(ctx, first.ID))
+
+	held := CreateNewTicket(flight.ID, john.ID, "", "2A", "", flight.Departure, flight.Arrival)
+	assert.NoError(t, store.HoldTicket(ctx, held, time.Hour))
+	assert.ErrorIs(t, store.ConfirmHold(ctx, "42"), ErrTicketNotFound)
+
+	m := store.metrics
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.bookingsCreated.WithLabelValues(kindTicket)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.bookingsCreated.WithLabelValues(kindRecord)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.cancellations.WithLabelValues(kindTicket)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.failedBookings.WithLabelValues("seat_taken")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.failedBookings.WithLabelValues("sold_out")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.failedBookings.WithLabelValues("ticket_not_found")))
+
+	remaining, err := store.SeatsRemaining(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{flight.ID: 0}, remaining)
+	assert.Equal(t, 1, testutil.CollectAndCount(NewSeatsCollector(store, store.logger)))
+}
+
+func TestEventsReportedAfterUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	store, flight, john := newTestMemoryStore(t)
+	newTicket := func(seat string) *Ticket {
+		return CreateNewTicket(flight.ID, john.ID, StatusBooked, seat, "", flight.Departure, flight.Arrival)
+	}
+	m := store.metrics
+
+	// a rolled back or retried attempt reports its failures but none of its bookings
+	events := &Events{}
+	attempt := store.WithEvents(events)
+	assert.NoError(t, attempt.CreateTicket(ctx, newTicket("1A")))
+	assert.ErrorIs(t, attempt.CreateTicket(ctx, newTicket("1A")), ErrSeatTaken)
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.bookingsCreated.WithLabelValues(kindTicket)), "events wait for the unit of work")
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.failedBookings.WithLabelValues("seat_taken")))
+	events.Flush(false)
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.bookingsCreated.WithLabelValues(kindTicket)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.failedBookings.WithLabelValues("seat_taken")))
+
+	events = &Events{}
+	assert.NoError(t, store.WithEvents(events).CreateTicket(ctx, newTicket("1C")))
+	events.Flush(true)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.bookingsCreated.WithLabelValues(kindTicket)))
+	events.Flush(true)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.bookingsCreated.WithLabelValues(kindTicket)), "events are reported once")
+}
@@ -0,0 +1,254 @@
+package booking
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"flightticketservice/pkg/apperr"
+	"flightticketservice/pkg/database"
+	"flightticketservice/utils"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrBookingNotFound is returned when no booking matches locator and last name.
+var ErrBookingNotFound = apperr.New(apperr.ErrNotFound, "booking_not_found", "booking not found")
+
+// locatorAlphabet skips characters easily confused when read aloud or handwritten (0/O, 1/I).
+const locatorAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// locatorLength is the length of PNR locator.
+const locatorLength = 6
+
+// locatorAttempts is how many times a new locator is generated on collision.
+const locatorAttempts = 5
+
+// NewLocator generates random PNR locator, e.g. "K7QX2M".
+func NewLocator() (string, error) {
+	locator := make([]byte, locatorLength)
+	size := big.NewInt(int64(len(locatorAlphabet)))
+	for i := range locator {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		locator[i] = locatorAlphabet[n.Int64()]
+	}
+	return string(locator), nil
+}
+
+// CreateBooking creates booking record with all tickets or none of them
+// @Summary Create booking record
+// @Description Books several passengers on several flight segments under one 6-character locator.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param booking body CreateBookingReq true "Passengers and segments"
+// @Success 201 {object} BookingRecord
+// @Failure 400 "Invalid booking data"
+// @Failure 404 "Passenger or flight not found"
+// @Failure 409 "Flight is sold out or seat is taken"
+// @Router /api/v1/bookings/create [post]
+func (bs *BookingStore) CreateBooking(ctx context.Context, tickets []*Ticket) (_ *BookingRecord, err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.CreateBooking")
+	defer func() { utils.EndSpan(span, err) }()
+
+	if len(tickets) == 0 {
+		return nil, errors.New("booking must have at least one ticket")
+	}
+
+	for attempt := 1; ; attempt++ {
+		record, err := bs.createBooking(ctx, tickets)
+		if isLocatorCollision(err) && attempt < locatorAttempts {
+			continue
+		}
+		if err != nil {
+			bs.events.failed(func() { bs.metrics.failed(err) })
+			return nil, err
+		}
+
+		span.SetAttributes(utils.LocatorKey.String(record.Locator), utils.RowsKey.Int(len(record.Tickets)))
+		bs.events.done(func() {
+			logBookingCreated(ctx, bs.logger, record)
+			bs.metrics.booked(kindRecord, len(record.Tickets))
+		})
+		return record, nil
+	}
+}
+
+// createBooking inserts booking record with a new locator and its tickets in one transaction.
+func (bs *BookingStore) createBooking(ctx context.Context, tickets []*Ticket) (*BookingRecord, error) {
+	locator, err := NewLocator()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &BookingRecord{Locator: locator, CreatedAt: time.Now().UTC(), Tickets: tickets}
+	err = database.RunInTx(ctx, bs.db, func(tx *sql.Tx) error {
+		query := `insert into booking_records (locator, created_at) values ($1, $2)`
+		if _, err := tx.ExecContext(ctx, query, record.Locator, record.CreatedAt); err != nil {
+			return err
+		}
+
+		for _, ticket := range tickets {
+			ticket.Locator = record.Locator
+			if err := insertTicket(ctx, tx, ticket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, ticket := range tickets {
+			ticket.ID, ticket.Locator = "", ""
+		}
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// isLocatorCollision reports if err is caused by generated locator that is already used.
+func isLocatorCollision(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "booking_records_pkey"
+}
+
+// GetBooking returns booking record if one of its passengers has the last name
+// @Summary Get booking record
+// @Description Returns booking record by locator and last name of one of its passengers.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param locator path string true "Booking locator"
+// @Param last_name query string true "Last name of a passenger in the booking"
+// @Success 200 {object} BookingRecord
+// @Failure 404 "Booking not found"
+// @Router /api/v1/bookings/{locator} [get]
+func (bs *BookingStore) GetBooking(ctx context.Context, locator, lastName string) (_ *BookingRecord, err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.GetBooking", trace.WithAttributes(utils.LocatorKey.String(locator)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	record := &BookingRecord{Locator: locator}
+
+	query := `select r.created_at from booking_records r
+	where r.locator = $1 and exists (
+		select 1 from booking_flights b join passengers p on p.id = b.passenger_id
+		where b.locator = r.locator and lower(p.last_name) = lower($2)
+	)`
+	err = bs.db.QueryRowContext(ctx, query, locator, lastName).Scan(&record.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBookingNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := bs.db.QueryContext(ctx, `select `+ticketColumns+` from booking_flights where locator = $1 order by id`, locator)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
+		}
+		record.Tickets = append(record.Tickets, ticket)
+	}
+
+	utils.SetRows(ctx, int64(len(record.Tickets)))
+	return record, rows.Err()
+}
+
+// CancelBooking cancels all active tickets of the booking record in one transaction
+// @Summary Cancel booking record
+// @Description Cancels every ticket of the booking. Fails without changes if any ticket cannot be cancelled.
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param locator path string true "Booking locator"
+// @Param last_name query string true "Last name of a passenger in the booking"
+// @Success 200 "Booking cancelled"
+// @Failure 404 "Booking not found"
+// @Failure 409 "Ticket cannot be cancelled in its current status"
+// @Router /api/v1/bookings/{locator}/cancel [post]
+func (bs *BookingStore) CancelBooking(ctx context.Context, locator, lastName string) (err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.CancelBooking", trace.WithAttributes(utils.LocatorKey.String(locator)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	if _, err := bs.GetBooking(ctx, locator, lastName); err != nil {
+		return err
+	}
+
+	var cancelled int
+	err = database.RunInTx(ctx, bs.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `select id, status from booking_flights where locator = $1 for update`, locator)
+		if err != nil {
+			return err
+		}
+
+		statuses := map[string]Status{}
+		for rows.Next() {
+			var (
+				id     string
+				status Status
+			)
+			if err := rows.Scan(&id, &status); err != nil {
+				rows.Close()
+				return err
+			}
+			statuses[id] = status
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		ids := []string{}
+		for id, status := range statuses {
+			if status == StatusCancelled || status == StatusRefunded {
+				continue
+			}
+			if err := Transition(status, StatusCancelled); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+
+		if len(ids) == 0 {
+			return &TransitionError{From: StatusCancelled, To: StatusCancelled}
+		}
+
+		query := `update booking_flights set status = $1 where id = any($2::int[])`
+		cancelled = len(ids)
+		_, err = tx.ExecContext(ctx, query, StatusCancelled, pq.Array(ids))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	utils.SetRows(ctx, int64(cancelled))
+	bs.events.done(func() {
+		bs.logger.InfoContext(ctx, "booking cancelled", "locator", locator)
+		bs.metrics.cancelled(kindRecord, cancelled)
+	})
+	return nil
+}
+
+// logBookingCreated logs new booking record event with IDs of its tickets.
+func logBookingCreated(ctx context.Context, logger *slog.Logger, record *BookingRecord) {
+	ids := make([]string, 0, len(record.Tickets))
+	for _, ticket := range record.Tickets {
+		ids = append(ids, ticket.ID)
+	}
+	logger.InfoContext(ctx, "booking created", "locator", record.Locator, "ticket_ids", ids)
+}
@@ -0,0 +1,24 @@
+package booking
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLocator(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		locator, err := NewLocator()
+		assert.NoError(t, err)
+		assert.Len(t, locator, locatorLength)
+
+		for _, c := range locator {
+			assert.True(t, strings.ContainsRune(locatorAlphabet, c), "unexpected character %q", c)
+		}
+		seen[locator] = true
+	}
+
+	assert.Greater(t, len(seen), 90, "Expected locators to be random")
+}
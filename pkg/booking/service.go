@@ -1,54 +1,74 @@
 package booking
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"time"
+
+	"flightticketservice/pkg/apperr"
+	"flightticketservice/pkg/database"
+	"flightticketservice/pkg/pagination"
+	"flightticketservice/utils"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts spans of store methods, statements they run are traced by the database driver.
+var tracer = otel.Tracer("flightticketservice/pkg/booking")
+
+// Ticket store errors.
+var (
+	ErrSeatTaken      = apperr.New(apperr.ErrConflict, "seat_taken", "seat is already taken on this flight")
+	ErrTicketNotFound = apperr.New(apperr.ErrNotFound, "ticket_not_found", "ticket not found")
+	ErrNotTicketOwner = apperr.New(apperr.ErrConflict, "not_ticket_owner", "ticket belongs to another passenger")
+	ErrFlightMismatch = apperr.New(apperr.ErrConflict, "flight_mismatch", "ticket is held on another flight, change the flight of the ticket instead")
 )
 
 // BookingService interface inmplements methods for booking.
 type BookingService interface {
-	GetTickets() ([]*Ticket, error)
-	GetTicketByID(ticketID string) (*Ticket, error)
-	BookTicket(ticketID, flightID, passengerID, additionalInfo string) error
-	CancelTicket(ticketID string) error
-	ChangeFlight(ticketID string, newFlightID string) error
-	CreateTicket(newTicket *Ticket) error
-	UpdateTicket(id string, newTicket *Ticket) error
-	DeleteTicket(ticketID string) error
+	GetTickets(ctx context.Context, params pagination.Params) (*pagination.Page[*Ticket], error)
+	GetTicketByID(ctx context.Context, ticketID string) (*Ticket, error)
+	BookTicket(ctx context.Context, ticketID, flightID, passengerID, additionalInfo string) error
+	CancelTicket(ctx context.Context, ticketID string) error
+	ChangeFlight(ctx context.Context, ticketID string, newFlightID string) error
+	CreateTicket(ctx context.Context, newTicket *Ticket) error
+	UpdateTicket(ctx context.Context, id string, newTicket *Ticket) error
+	DeleteTicket(ctx context.Context, ticketID string) error
+	CheckIn(ctx context.Context, ticketID, seatNumber string, departure time.Time) (*Ticket, error)
+	GetTakenSeats(ctx context.Context, flightID string) ([]string, error)
+	HoldTicket(ctx context.Context, ticket *Ticket, ttl time.Duration) error
+	ConfirmHold(ctx context.Context, ticketID string) error
+	ReleaseExpiredHolds(ctx context.Context) (int64, error)
+	CreateBooking(ctx context.Context, tickets []*Ticket) (*BookingRecord, error)
+	GetBooking(ctx context.Context, locator, lastName string) (*BookingRecord, error)
+	CancelBooking(ctx context.Context, locator, lastName string) error
+	SeatsRemaining(ctx context.Context) (map[string]int, error)
 }
 
 // BookingStore structure implements interface FlightService.
 type BookingStore struct {
-	db *sql.DB
+	db      database.Querier
+	checkIn CheckInWindow
+	logger  *slog.Logger
+	metrics *Metrics
+	events  *Events // nil reports booking events at once
 }
 
-// NewBookingStore initializes a new PostgresStore with a shared database connection.
-func NewBookingStore(db *sql.DB) *BookingStore {
-	return &BookingStore{db: db}
+// NewBookingStore creates ticket store on db, booking events are logged to logger and counted by metrics.
+func NewBookingStore(db database.Querier, logger *slog.Logger, metrics *Metrics) *BookingStore {
+	return &BookingStore{db: db, checkIn: DefaultCheckInWindow, logger: logger, metrics: metrics}
 }
 
-// Init initializes db with data
-func (bs *BookingStore) Init() error {
-	return bs.CreateFlightsTable()
-}
-
-// CreateFlightsTable creates flights table in db
-func (bs *BookingStore) CreateFlightsTable() error {
-	query := `CREATE TABLE IF NOT EXISTS booking_flights (
-		ID SERIAL PRIMARY KEY,
-		flight_id VARCHAR(10) NOT NULL,
-		passenger_id VARCHAR(10) NOT NULL,
-		booking_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		departure_time TIMESTAMP NOT NULL,
-		arrival_time TIMESTAMP NOT NULL,
-		status VARCHAR(30) NOT NULL CHECK (status IN ("booked", "cancelled", "confirmed")),
-		seat_number VARCHAR(30),
-		additional_info VARCHAR(100)
-	)`
-
-	_, err := bs.db.Exec(query)
-	return err
+// WithEvents returns copy of the store that keeps booking events in events until its unit of work ends.
+func (bs *BookingStore) WithEvents(events *Events) *BookingStore {
+	copied := *bs
+	copied.events = events
+	return &copied
 }
 
 // CreateTicket creates ticket in table
@@ -60,8 +80,32 @@ func (bs *BookingStore) CreateFlightsTable() error {
 // @Param flight body CreateTicketReq true "Ticket data"
 // @Success 200 "Ticket created"
 // @Failure 400 "Invalid ticket data"
+// @Failure 409 "Flight is sold out"
 // @Router /api/v1/tickets/create [post]
-func (bs *BookingStore) CreateTicket(ticket *Ticket) error {
+func (bs *BookingStore) CreateTicket(ctx context.Context, ticket *Ticket) (err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.CreateTicket", trace.WithAttributes(utils.FlightIDKey.String(ticket.FlightID)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	err = database.RunInTx(ctx, bs.db, func(tx *sql.Tx) error {
+		return insertTicket(ctx, tx, ticket)
+	})
+	if err != nil {
+		bs.events.failed(func() { bs.metrics.failed(err) })
+		return err
+	}
+
+	span.SetAttributes(utils.TicketIDKey.String(ticket.ID))
+	bs.events.done(func() {
+		logTicketCreated(ctx, bs.logger, ticket)
+		if ticket.Status == StatusBooked {
+			bs.metrics.booked(kindTicket, 1)
+		}
+	})
+	return nil
+}
+
+// insertTicket reserves a seat on the flight and inserts ticket, setting its ID.
+func insertTicket(ctx context.Context, tx *sql.Tx, ticket *Ticket) error {
 	query := `insert into booking_flights (
 		flight_id,
 		passenger_id,
@@ -70,11 +114,23 @@ func (bs *BookingStore) CreateTicket(ticket *Ticket) error {
 		arrival_time,
 		status,
 		seat_number,
-		additional_info
+		additional_info,
+		hold_expires_at,
+		locator
 	)
-	values ($1, $2, $3, $4, $5, $6, $7, $8)`
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	returning id`
+
+	if err := Transition(statusNone, ticket.Status); err != nil {
+		return err
+	}
 
-	resp, err := bs.db.Query(
+	if err := reserveSeat(ctx, tx, ticket.FlightID, ""); err != nil {
+		return err
+	}
+
+	err := tx.QueryRowContext(
+		ctx,
 		query,
 		ticket.FlightID,
 		ticket.PassengerID,
@@ -83,14 +139,11 @@ func (bs *BookingStore) CreateTicket(ticket *Ticket) error {
 		ticket.ArrivalTime,
 		ticket.Status,
 		ticket.SeatNumber,
-		ticket.AdditionalInfo)
-
-	if err != nil {
-		return err
-	}
+		ticket.AdditionalInfo,
+		ticket.HoldExpiresAt,
+		sql.NullString{String: ticket.Locator, Valid: ticket.Locator != ""}).Scan(&ticket.ID)
 
-	fmt.Printf("%+v\n", resp)
-	return nil
+	return seatError(err)
 }
 
 // BookTicket books a new ticket
@@ -105,29 +158,66 @@ func (bs *BookingStore) CreateTicket(ticket *Ticket) error {
 // @Param additionalInfo query string false "Additional Information"
 // @Success 200 "Ticket successfully booked"
 // @Failure 400 "Invalid ticket data"
+// @Failure 403 "Ticket or passenger of another account"
+// @Failure 409 "Flight is sold out, ticket belongs to another passenger or is held on another flight"
 // @Router /api/v1/tickets/book [post]
-func (bs *BookingStore) BookTicket(id, flightID, passengerID, additionalInfo string) error {
+func (bs *BookingStore) BookTicket(ctx context.Context, id, flightID, passengerID, additionalInfo string) (err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.BookTicket", trace.WithAttributes(
+		utils.TicketIDKey.String(id),
+		utils.FlightIDKey.String(flightID),
+		utils.PassengerIDKey.String(passengerID),
+	))
+	defer func() { utils.EndSpan(span, err) }()
+
 	if id == "" {
 		return errors.New("ticket ID cannot be empty")
 	}
 
 	query := `UPDATE booking_flights
-	SET status = $3, passenger_id = $2, additional_info = $4
-	WHERE ID = $1;`
+	SET status = $3, additional_info = $4, hold_expires_at = NULL
+	WHERE ID = $1 AND passenger_id = $2 AND flight_id = $5;`
 
-	resp, err := bs.db.Query(
-		query,
-		id,
-		passengerID,
-		"booked",
-		additionalInfo,
-	)
+	err = database.RunInTx(ctx, bs.db, func(tx *sql.Tx) error {
+		locked, err := lockTicket(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := locked.confirmHold(time.Now().UTC()); err != nil {
+			return err
+		}
+		if locked.passengerID != passengerID {
+			return ErrNotTicketOwner
+		}
+		if locked.flightID != flightID {
+			return ErrFlightMismatch
+		}
+
+		if err := reserveSeat(ctx, tx, flightID, id); err != nil {
+			return err
+		}
 
+		_, err = tx.ExecContext(
+			ctx,
+			query,
+			id,
+			passengerID,
+			StatusBooked,
+			additionalInfo,
+			flightID,
+		)
+
+		return seatError(err)
+	})
 	if err != nil {
+		bs.events.failed(func() { bs.metrics.failed(err) })
 		return err
 	}
 
-	fmt.Printf("%+v\n", resp)
+	bs.events.done(func() {
+		bs.logger.InfoContext(ctx, "ticket booked", "ticket_id", id, "flight_id", flightID, "passenger_id", passengerID)
+		bs.metrics.booked(kindTicket, 1)
+	})
 	return nil
 }
 
@@ -140,27 +230,39 @@ func (bs *BookingStore) BookTicket(id, flightID, passengerID, additionalInfo str
 // @Param ticketID path string true "The ID of the ticket to cancel"
 // @Success 200 "Ticket successfully cancelled"
 // @Failure 404 "Ticket not found"
+// @Failure 409 "Ticket cannot be cancelled in its current status"
 // @Router /api/v1/tickets/{ticketID}/cancel [post]
-func (bs *BookingStore) CancelTicket(ticketID string) error {
+func (bs *BookingStore) CancelTicket(ctx context.Context, ticketID string) (err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.CancelTicket", trace.WithAttributes(utils.TicketIDKey.String(ticketID)))
+	defer func() { utils.EndSpan(span, err) }()
+
 	if ticketID == "" {
 		return errors.New("ticket ID cannot be empty")
 	}
 
-	query := `update booking_flights set status = 'cancelled' where id = $1 and status != 'cancelled'`
-	res, err := bs.db.Exec(query, ticketID)
-	if err != nil {
-		return err
-	}
+	query := `update booking_flights set status = $1 where id = $2`
 
-	rowsAffected, err := res.RowsAffected()
+	err = database.RunInTx(ctx, bs.db, func(tx *sql.Tx) error {
+		locked, err := lockTicket(ctx, tx, ticketID)
+		if err != nil {
+			return err
+		}
+
+		if err := Transition(locked.status, StatusCancelled); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, StatusCancelled, ticketID)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("ticket not found or already cancelled")
-	}
-
+	bs.events.done(func() {
+		bs.logger.InfoContext(ctx, "ticket cancelled", "ticket_id", ticketID)
+		bs.metrics.cancelled(kindTicket, 1)
+	})
 	return nil
 }
 
@@ -170,32 +272,53 @@ func (bs *BookingStore) CancelTicket(ticketID string) error {
 // @Tags booking
 // @Accept json
 // @Produce json
-// @Param ticketID path string true "The ID of the ticket to update"
-// @Param newFlightID query string true "The new flight ID to associate with the ticket"
+// @Param id path string true "The ID of the ticket to update"
+// @Param flightID query string true "The new flight ID to associate with the ticket"
 // @Success 200 "Flight successfully changed for the ticket"
 // @Failure 400 "Invalid parameters"
-// @Failure 404 "Ticket not found or cannot change flight for a cancelled ticket"
-// @Router /api/v1/{ticketID}/change [post]
-func (bs *BookingStore) ChangeFlight(ticketID string, newFlightID string) error {
+// @Failure 404 "Ticket not found"
+// @Failure 409 "Flight is sold out or ticket cannot change flight in its current status"
+// @Router /api/v1/tickets/{id}/change [post]
+func (bs *BookingStore) ChangeFlight(ctx context.Context, ticketID string, newFlightID string) (err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.ChangeFlight", trace.WithAttributes(
+		utils.TicketIDKey.String(ticketID),
+		utils.FlightIDKey.String(newFlightID),
+	))
+	defer func() { utils.EndSpan(span, err) }()
+
 	if ticketID == "" || newFlightID == "" {
 		return errors.New("ticket ID and new flight ID cannot be empty")
 	}
 
-	query := `update booking_flights set flight_id = $1, status = 'confirmed' where id = $2 and status != 'cancelled'`
-	res, err := bs.db.Exec(query, newFlightID, ticketID)
-	if err != nil {
-		return err
-	}
+	// the ticket departs and arrives with its new flight
+	query := `update booking_flights b set flight_id = f.id, departure_time = f.departure, arrival_time = f.arrival
+	from flights f where f.id = $1 and b.id = $2`
 
-	rowsAffected, err := res.RowsAffected()
+	err = database.RunInTx(ctx, bs.db, func(tx *sql.Tx) error {
+		locked, err := lockTicket(ctx, tx, ticketID)
+		if err != nil {
+			return err
+		}
+
+		if !locked.status.CanChangeFlight() {
+			return fmt.Errorf("%w: cannot change flight of %s ticket", ErrInvalidTransition, locked.status)
+		}
+
+		if err := reserveSeat(ctx, tx, newFlightID, ticketID); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, newFlightID, ticketID)
+		return seatError(err)
+	})
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("ticket not found or cannot change flight for a cancelled ticket")
-	}
-
+	bs.events.done(func() {
+		bs.logger.InfoContext(ctx, "ticket flight changed", "ticket_id", ticketID, "flight_id", newFlightID)
+		bs.metrics.flightChanged()
+	})
 	return nil
 }
 
@@ -209,67 +332,95 @@ func (bs *BookingStore) ChangeFlight(ticketID string, newFlightID string) error
 // @Success 200 {object} Ticket
 // @Failure 404 "ticket not found"
 // @Router /api/v1/tickets/{id} [get]
-func (bs *BookingStore) GetTicketByID(ticketID string) (*Ticket, error) {
-	query := `select * from booking_flights where id = $1`
-	row := bs.db.QueryRow(query, ticketID)
+func (bs *BookingStore) GetTicketByID(ctx context.Context, ticketID string) (_ *Ticket, err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.GetTicketByID", trace.WithAttributes(utils.TicketIDKey.String(ticketID)))
+	defer func() { utils.EndSpan(span, err) }()
 
-	ticket := &Ticket{}
-	err := row.Scan(
-		&ticket.ID,
-		&ticket.FlightID,
-		&ticket.PassengerID,
-		&ticket.BookingTime,
-		&ticket.DepartureTime,
-		&ticket.ArrivalTime,
-		&ticket.Status,
-		&ticket.SeatNumber,
-		&ticket.AdditionalInfo,
-	)
+	if !validID(ticketID) {
+		return nil, ErrTicketNotFound
+	}
+
+	query := `select ` + ticketColumns + ` from booking_flights where id = $1`
+	row := bs.db.QueryRowContext(ctx, query, ticketID)
+
+	ticket, err := scanTicket(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("ticket not found")
+			return nil, ErrTicketNotFound
 		}
 		return nil, err
 	}
 
+	span.SetAttributes(utils.FlightIDKey.String(ticket.FlightID))
 	return ticket, nil
 }
 
-// GetTickets returns a list of all tickets
+// GetTickets returns page of tickets
 // @Summary Get list of tickets
-// @Description get tickets
+// @Description get tickets page by page
 // @Tags tickets
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} Ticket
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort key, prefix with - for descending" Enums(id, -id, booking_time, -booking_time, departure_time, -departure_time)
+// @Param status query string false "Ticket status"
+// @Param flight_id query string false "Flight of the ticket"
+// @Param passenger_id query string false "Passenger of the ticket"
+// @Param locator query string false "Booking locator"
+// @Success 200 {object} pagination.Page[booking.Ticket]
+// @Failure 400 "Invalid pagination parameters or unknown filter"
 // @Router /api/v1/tickets [get]
-func (bs *BookingStore) GetTickets() ([]*Ticket, error) {
-	rows, err := bs.db.Query("select * from booking_flights")
+func (bs *BookingStore) GetTickets(ctx context.Context, params pagination.Params) (page *pagination.Page[*Ticket], err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.GetTickets")
+	defer func() { utils.EndSpan(span, err) }()
+
+	scan := func(rows *sql.Rows) (*Ticket, error) { return scanTicket(rows) }
+	page, err = pagination.Fetch(ctx, bs.db, listTickets, params, scan, ticketField)
 	if err != nil {
 		return nil, err
 	}
 
-	tickets := []*Ticket{}
-	for rows.Next() {
-		ticket := &Ticket{}
-		err := rows.Scan(
-			&ticket.ID,
-			&ticket.FlightID,
-			&ticket.PassengerID,
-			&ticket.BookingTime,
-			&ticket.DepartureTime,
-			&ticket.ArrivalTime,
-			&ticket.Status,
-			&ticket.SeatNumber,
-			&ticket.AdditionalInfo,
-		)
-		if err != nil {
-			return nil, err
-		}
-		tickets = append(tickets, ticket)
-	}
+	utils.SetRows(ctx, int64(len(page.Items)))
+	return page, nil
+}
 
-	return tickets, nil
+// listTickets describes sort keys and filters of tickets list.
+var listTickets = &pagination.Query{
+	Columns: ticketColumns,
+	From:    "booking_flights",
+	ID:      pagination.Column{Expr: "id", Type: "int"},
+	Sorts: map[string]pagination.Column{
+		"id":             {Expr: "id", Type: "int"},
+		"booking_time":   {Expr: "booking_time", Type: "timestamp"},
+		"departure_time": {Expr: "departure_time", Type: "timestamp"},
+	},
+	DefaultSort: "id",
+	Filters: map[string]pagination.Column{
+		"status":       {Expr: "status"},
+		"flight_id":    {Expr: "flight_id::text"},
+		"passenger_id": {Expr: "passenger_id::text"},
+		"locator":      {Expr: "locator"},
+	},
+}
+
+// ticketField returns ticket field by sort key or filter name of listTickets.
+func ticketField(ticket *Ticket, name string) string {
+	switch name {
+	case "booking_time":
+		return ticket.BookingTime.Format(time.RFC3339Nano)
+	case "departure_time":
+		return ticket.DepartureTime.Format(time.RFC3339Nano)
+	case "status":
+		return string(ticket.Status)
+	case "flight_id":
+		return ticket.FlightID
+	case "passenger_id":
+		return ticket.PassengerID
+	case "locator":
+		return ticket.Locator
+	}
+	return ticket.ID
 }
 
 // UpdateTicket updates the details of an existing ticket
@@ -283,8 +434,12 @@ func (bs *BookingStore) GetTickets() ([]*Ticket, error) {
 // @Success 200 "Ticket successfully updated"
 // @Failure 400 "Invalid ticket data"
 // @Failure 404 "Ticket not found"
+// @Failure 409 "Ticket cannot move to the requested status"
 // @Router /api/v1/tickets/{id}/update [post]
-func (bs *BookingStore) UpdateTicket(id string, newTicket *Ticket) error {
+func (bs *BookingStore) UpdateTicket(ctx context.Context, id string, newTicket *Ticket) (err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.UpdateTicket", trace.WithAttributes(utils.TicketIDKey.String(id)))
+	defer func() { utils.EndSpan(span, err) }()
+
 	if newTicket == nil {
 		return errors.New("ticket cannot be nil")
 	}
@@ -300,32 +455,54 @@ func (bs *BookingStore) UpdateTicket(id string, newTicket *Ticket) error {
 	additional_info = $8
 	WHERE id = $9`
 
-	res, err := bs.db.Exec(
-		query,
-		newTicket.FlightID,
-		newTicket.PassengerID,
-		newTicket.BookingTime,
-		newTicket.DepartureTime,
-		newTicket.ArrivalTime,
-		newTicket.Status,
-		newTicket.SeatNumber,
-		newTicket.AdditionalInfo,
-		newTicket.ID,
-	)
+	span.SetAttributes(utils.FlightIDKey.String(newTicket.FlightID))
 
-	if err != nil {
-		return err
-	}
+	var newStatus Status
+	err = database.RunInTx(ctx, bs.db, func(tx *sql.Tx) error {
+		locked, err := lockTicket(ctx, tx, id)
+		if err != nil {
+			return err
+		}
 
-	rowsAffected, err := res.RowsAffected()
+		newStatus = newTicket.Status
+		if newStatus == statusNone {
+			newStatus = locked.status
+		} else if err := Transition(locked.status, newStatus); err != nil {
+			return err
+		}
+
+		if newTicket.FlightID != locked.flightID {
+			if !locked.status.CanChangeFlight() {
+				return fmt.Errorf("%w: cannot change flight of %s ticket", ErrInvalidTransition, locked.status)
+			}
+			if err := reserveSeat(ctx, tx, newTicket.FlightID, id); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			query,
+			newTicket.FlightID,
+			newTicket.PassengerID,
+			newTicket.BookingTime,
+			newTicket.DepartureTime,
+			newTicket.ArrivalTime,
+			newStatus,
+			newTicket.SeatNumber,
+			newTicket.AdditionalInfo,
+			id,
+		)
+
+		return seatError(err)
+	})
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("ticket not found")
-	}
-
+	bs.events.done(func() {
+		bs.logger.InfoContext(ctx, "ticket updated", "ticket_id", id, "flight_id", newTicket.FlightID, "status", newStatus)
+	})
 	return nil
 }
 
@@ -339,10 +516,17 @@ func (bs *BookingStore) UpdateTicket(id string, newTicket *Ticket) error {
 // @Success 200 "Ticket successfully deleted"
 // @Failure 404 "Ticket not found"
 // @Router /api/v1/tickets/{id}/delete [delete]
-func (bs *BookingStore) DeleteTicket(ticketID string) error {
+func (bs *BookingStore) DeleteTicket(ctx context.Context, ticketID string) (err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.DeleteTicket", trace.WithAttributes(utils.TicketIDKey.String(ticketID)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	if !validID(ticketID) {
+		return ErrTicketNotFound
+	}
+
 	query := `delete from booking_flights where id = $1`
 
-	res, err := bs.db.Exec(query, ticketID)
+	res, err := bs.db.ExecContext(ctx, query, ticketID)
 	if err != nil {
 		return err
 	}
@@ -351,10 +535,192 @@ func (bs *BookingStore) DeleteTicket(ticketID string) error {
 	if err != nil {
 		return err
 	}
+	utils.SetRows(ctx, rowsAffected)
 
 	if rowsAffected == 0 {
-		return errors.New("ticket not found")
+		return ErrTicketNotFound
 	}
 
+	bs.events.done(func() {
+		bs.logger.InfoContext(ctx, "ticket deleted", "ticket_id", ticketID)
+	})
 	return nil
 }
+
+// CheckIn checks passenger in for the flight online
+// @Summary Online check-in
+// @Description Checks ticket in for the flight and assigns or confirms a seat. Available from 24 to 1 hours before departure.
+// @Tags booking
+// @Accept json
+// @Produce json
+// @Param checkin body CheckInReq true "Check-in data"
+// @Success 200 {object} Ticket
+// @Failure 400 "Seat number is required"
+// @Failure 404 "Ticket not found"
+// @Failure 409 "Ticket is cancelled, already checked in or cannot be checked in"
+// @Failure 422 "Check-in is not open yet or already closed"
+// @Router /api/v1/checkin [post]
+func (bs *BookingStore) CheckIn(ctx context.Context, ticketID, seatNumber string, departure time.Time) (_ *Ticket, err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.CheckIn", trace.WithAttributes(utils.TicketIDKey.String(ticketID)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	ticket, err := bs.GetTicketByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CanCheckIn(ticket.Status); err != nil {
+		return nil, err
+	}
+
+	if err := bs.checkIn.Validate(departure, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	if seatNumber == "" {
+		seatNumber = ticket.SeatNumber
+	}
+	if seatNumber == "" {
+		return nil, ErrSeatRequired
+	}
+
+	query := `update booking_flights set status = $1, seat_number = $2 where id = $3`
+
+	err = database.RunInTx(ctx, bs.db, func(tx *sql.Tx) error {
+		locked, err := lockTicket(ctx, tx, ticketID)
+		if err != nil {
+			return err
+		}
+
+		if err := CanCheckIn(locked.status); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, StatusCheckedIn, seatNumber, ticketID)
+		return seatError(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ticket.Status = StatusCheckedIn
+	ticket.SeatNumber = seatNumber
+	bs.events.done(func() {
+		bs.logger.InfoContext(ctx, "ticket checked in", "ticket_id", ticketID, "seat_number", seatNumber)
+	})
+	return ticket, nil
+}
+
+// GetTakenSeats returns seats held by active tickets on the flight
+func (bs *BookingStore) GetTakenSeats(ctx context.Context, flightID string) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "BookingStore.GetTakenSeats", trace.WithAttributes(utils.FlightIDKey.String(flightID)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	if !validID(flightID) {
+		return []string{}, nil
+	}
+
+	query := `select seat_number from booking_flights
+	where flight_id = $1 and status not in ` + inactiveStatuses + ` and seat_number <> ''`
+
+	rows, err := bs.db.QueryContext(ctx, query, flightID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seats := []string{}
+	for rows.Next() {
+		var seat string
+		if err := rows.Scan(&seat); err != nil {
+			return nil, err
+		}
+		seats = append(seats, seat)
+	}
+
+	utils.SetRows(ctx, int64(len(seats)))
+	return seats, rows.Err()
+}
+
+// logTicketCreated logs new ticket event with its flight, passenger and status.
+func logTicketCreated(ctx context.Context, logger *slog.Logger, ticket *Ticket) {
+	logger.InfoContext(ctx, "ticket created",
+		"ticket_id", ticket.ID,
+		"flight_id", ticket.FlightID,
+		"passenger_id", ticket.PassengerID,
+		"status", ticket.Status,
+	)
+}
+
+// SeatsRemaining returns number of seats not taken by active tickets by ID of flight that has not departed yet.
+// It is not traced, it runs on every metrics scrape.
+func (bs *BookingStore) SeatsRemaining(ctx context.Context) (map[string]int, error) {
+	query := `select f.id, f.capacity - count(b.id) from flights f
+	left join booking_flights b on b.flight_id = f.id and b.status not in ` + inactiveStatuses + `
+	where f.departure > $1
+	group by f.id, f.capacity`
+
+	rows, err := bs.db.QueryContext(ctx, query, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	remaining := map[string]int{}
+	for rows.Next() {
+		var (
+			flightID string
+			seats    int
+		)
+		if err := rows.Scan(&flightID, &seats); err != nil {
+			return nil, err
+		}
+		remaining[flightID] = seats
+	}
+
+	return remaining, rows.Err()
+}
+
+// seatError converts unique violation of the seat index to ErrSeatTaken.
+func seatError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "booking_flights_active_seat_idx" {
+		return ErrSeatTaken
+	}
+	return err
+}
+
+// ticketColumns lists booking_flights columns in the order scanTicket reads them.
+const ticketColumns = `id, flight_id, passenger_id, booking_time, departure_time, arrival_time,
+	status, seat_number, additional_info, hold_expires_at, locator`
+
+func scanTicket(row interface{ Scan(dest ...any) error }) (*Ticket, error) {
+	ticket := &Ticket{}
+	var (
+		holdExpiresAt sql.NullTime
+		locator       sql.NullString
+	)
+	err := row.Scan(
+		&ticket.ID,
+		&ticket.FlightID,
+		&ticket.PassengerID,
+		&ticket.BookingTime,
+		&ticket.DepartureTime,
+		&ticket.ArrivalTime,
+		&ticket.Status,
+		&ticket.SeatNumber,
+		&ticket.AdditionalInfo,
+		&holdExpiresAt,
+		&locator,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket.Locator = locator.String
+	if holdExpiresAt.Valid {
+		ticket.HoldExpiresAt = &holdExpiresAt.Time
+	}
+
+	return ticket, nil
+}
@@ -0,0 +1,119 @@
+package booking
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"flightticketservice/pkg/apperr"
+)
+
+// Status is a ticket status.
+type Status string
+
+// Ticket statuses.
+const (
+	StatusHeld      Status = "held"
+	StatusBooked    Status = "booked"
+	StatusConfirmed Status = "confirmed"
+	StatusCheckedIn Status = "checked_in"
+	StatusBoarded   Status = "boarded"
+	StatusCancelled Status = "cancelled"
+	StatusRefunded  Status = "refunded"
+	StatusNoShow    Status = "no_show"
+)
+
+// statusNone is the status of a ticket that is not created yet.
+const statusNone = ""
+
+// ErrInvalidTransition is returned when ticket cannot move to the requested status.
+var ErrInvalidTransition = apperr.New(apperr.ErrConflict, "invalid_transition", "invalid ticket status transition")
+
+// transitions lists statuses ticket can move to from each status.
+var transitions = map[Status][]Status{
+	statusNone:      {StatusHeld, StatusBooked},
+	StatusHeld:      {StatusBooked, StatusCancelled},
+	StatusBooked:    {StatusConfirmed, StatusCheckedIn, StatusCancelled, StatusRefunded, StatusNoShow},
+	StatusConfirmed: {StatusCheckedIn, StatusCancelled, StatusRefunded, StatusNoShow},
+	StatusCheckedIn: {StatusBoarded, StatusNoShow},
+	StatusCancelled: {StatusRefunded},
+}
+
+// TransitionError describes rejected status transition.
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	if e.From == statusNone {
+		return fmt.Sprintf("%v: ticket cannot be created as %q", ErrInvalidTransition, e.To)
+	}
+	return fmt.Sprintf("%v: from %q to %q", ErrInvalidTransition, e.From, e.To)
+}
+
+// Unwrap makes TransitionError match ErrInvalidTransition and its kind.
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// inactiveStatuses lists statuses of tickets that do not hold a seat, for use in SQL.
+const inactiveStatuses = `('cancelled', 'refunded', 'no_show')`
+
+// CanChangeFlight reports if ticket in this status can be moved to another flight.
+func (s Status) CanChangeFlight() bool {
+	return s == StatusHeld || s == StatusBooked || s == StatusConfirmed
+}
+
+// CanTransition reports if ticket can move from s to status to.
+func (s Status) CanTransition(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition returns TransitionError if ticket cannot move from status from to status to.
+func Transition(from, to Status) error {
+	if !from.CanTransition(to) {
+		return &TransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// lockedTicket is a ticket row locked for update until transaction ends.
+type lockedTicket struct {
+	status        Status
+	flightID      string
+	passengerID   string
+	holdExpiresAt sql.NullTime
+}
+
+// lockTicket locks ticket row until tx ends and returns fields status checks depend on.
+func lockTicket(ctx context.Context, tx *sql.Tx, ticketID string) (*lockedTicket, error) {
+	query := `select status, flight_id, passenger_id, hold_expires_at from booking_flights where id = $1 for update`
+
+	if !validID(ticketID) {
+		return nil, ErrTicketNotFound
+	}
+
+	locked := &lockedTicket{}
+	err := tx.QueryRowContext(ctx, query, ticketID).Scan(&locked.status, &locked.flightID, &locked.passengerID, &locked.holdExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTicketNotFound
+		}
+		return nil, err
+	}
+	return locked, nil
+}
+
+// validID reports if id is a serial number of a ticket or flight,
+// other ids match no row and are not sent to integer columns.
+func validID(id string) bool {
+	_, err := strconv.ParseInt(id, 10, 32)
+	return err == nil
+}
@@ -0,0 +1,44 @@
+package booking
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransition(t *testing.T) {
+	assert.NoError(t, Transition(statusNone, StatusHeld))
+	assert.NoError(t, Transition(StatusHeld, StatusBooked))
+	assert.NoError(t, Transition(StatusBooked, StatusConfirmed))
+	assert.NoError(t, Transition(StatusConfirmed, StatusCheckedIn))
+	assert.NoError(t, Transition(StatusCheckedIn, StatusBoarded))
+	assert.NoError(t, Transition(StatusCancelled, StatusRefunded))
+
+	err := Transition(StatusCancelled, StatusBooked)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	var transitionErr *TransitionError
+	assert.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, StatusCancelled, transitionErr.From)
+	assert.Equal(t, StatusBooked, transitionErr.To)
+
+	assert.ErrorIs(t, Transition(StatusBoarded, StatusCancelled), ErrInvalidTransition)
+	assert.ErrorIs(t, Transition(statusNone, StatusCheckedIn), ErrInvalidTransition)
+	assert.ErrorIs(t, Transition(StatusBooked, "updated"), ErrInvalidTransition)
+}
+
+func TestStatusCanChangeFlight(t *testing.T) {
+	assert.True(t, StatusBooked.CanChangeFlight())
+	assert.True(t, StatusConfirmed.CanChangeFlight())
+	assert.False(t, StatusCheckedIn.CanChangeFlight())
+	assert.False(t, StatusCancelled.CanChangeFlight())
+}
+
+func TestValidID(t *testing.T) {
+	assert.True(t, validID("42"))
+	assert.False(t, validID(""))
+	assert.False(t, validID("abc"))
+	assert.False(t, validID("1; drop table"))
+	assert.False(t, validID("99999999999"))
+}
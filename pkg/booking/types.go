@@ -1,18 +1,32 @@
 package booking
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"flightticketservice/pkg/validate"
+)
+
+// Lengths of columns of booking_flights table.
+const (
+	idMaxLen             = 10
+	seatNumberMaxLen     = 30
+	additionalInfoMaxLen = 100
+)
 
 // Ticket collects info about ticket.
 type Ticket struct {
-	ID             string    `json:"id"`
-	FlightID       string    `json:"flight_id"`
-	PassengerID    string    `json:"passenger_id"`
-	BookingTime    time.Time `json:"booking_time"`
-	DepartureTime  time.Time `json:"departure_time"`
-	ArrivalTime    time.Time `json:"arrival_time"`
-	Status         string    `json:"status"` // "booked", "cancelled", "confirmed"
-	SeatNumber     string    `json:"seat_number"`
-	AdditionalInfo string    `json:"additional_info"`
+	ID             string     `json:"id"`
+	FlightID       string     `json:"flight_id"`
+	PassengerID    string     `json:"passenger_id"`
+	BookingTime    time.Time  `json:"booking_time"`
+	DepartureTime  time.Time  `json:"departure_time"`
+	ArrivalTime    time.Time  `json:"arrival_time"`
+	Status         Status     `json:"status"`
+	SeatNumber     string     `json:"seat_number"`
+	AdditionalInfo string     `json:"additional_info"`
+	HoldExpiresAt  *time.Time `json:"hold_expires_at,omitempty"`
+	Locator        string     `json:"locator,omitempty"`
 }
 
 // CreateTicketReq collects info about ticket for request.
@@ -25,8 +39,21 @@ type CreateTicketReq struct {
 	AdditionalInfo string    `json:"additional_info"`
 }
 
+// Validate checks fields of the request.
+func (r *CreateTicketReq) Validate() error {
+	return validate.All(
+		validate.Field("flight_id", r.FlightID, validate.Required[string](), validate.MaxLen(idMaxLen)),
+		validate.Field("passenger_id", r.PassengerID, validate.Required[string](), validate.UUID()),
+		validate.Field("departure_time", r.DepartureTime, validate.Required[time.Time]()),
+		validate.Field("arrival_time", r.ArrivalTime,
+			validate.Required[time.Time](), validate.After("departure_time", r.DepartureTime)),
+		validate.Field("seat_number", r.SeatNumber, validate.MaxLen(seatNumberMaxLen)),
+		validate.Field("additional_info", r.AdditionalInfo, validate.MaxLen(additionalInfoMaxLen)),
+	)
+}
+
 // CreateNewTicket creates new ticket by passed params
-func CreateNewTicket(flightID, passengerID, status, seat, additionalInfo string, departureTime, arrivalTime time.Time) *Ticket {
+func CreateNewTicket(flightID, passengerID string, status Status, seat, additionalInfo string, departureTime, arrivalTime time.Time) *Ticket {
 	return &Ticket{
 		FlightID:       flightID,
 		PassengerID:    passengerID,
@@ -38,3 +65,70 @@ func CreateNewTicket(flightID, passengerID, status, seat, additionalInfo string,
 		AdditionalInfo: additionalInfo,
 	}
 }
+
+// HoldTicketReq collects info about seat to hold before purchase.
+type HoldTicketReq struct {
+	FlightID       string `json:"flight_id"`
+	PassengerID    string `json:"passenger_id"`
+	SeatNumber     string `json:"seat_number"`
+	AdditionalInfo string `json:"additional_info"`
+}
+
+// Validate checks fields of the request.
+func (r *HoldTicketReq) Validate() error {
+	return validate.All(
+		validate.Field("flight_id", r.FlightID, validate.Required[string](), validate.MaxLen(idMaxLen)),
+		validate.Field("passenger_id", r.PassengerID, validate.Required[string](), validate.UUID()),
+		validate.Field("seat_number", r.SeatNumber, validate.MaxLen(seatNumberMaxLen)),
+		validate.Field("additional_info", r.AdditionalInfo, validate.MaxLen(additionalInfoMaxLen)),
+	)
+}
+
+// BookingRecord groups tickets of several passengers and flight segments under one locator.
+type BookingRecord struct {
+	Locator   string    `json:"locator"`
+	CreatedAt time.Time `json:"created_at"`
+	Tickets   []*Ticket `json:"tickets"`
+}
+
+// CreateBookingReq collects passengers and flight segments to book together.
+type CreateBookingReq struct {
+	PassengerIDs   []string     `json:"passenger_ids"`
+	Segments       []SegmentReq `json:"segments"`
+	AdditionalInfo string       `json:"additional_info"`
+}
+
+// SegmentReq collects flight of one segment and seats for passengers in CreateBookingReq order.
+type SegmentReq struct {
+	FlightID string   `json:"flight_id"`
+	Seats    []string `json:"seats,omitempty"`
+}
+
+// Validate checks passengers and segments of the request, seats of a segment are given for all passengers or none.
+func (r *CreateBookingReq) Validate() error {
+	checks := []validate.Check{
+		validate.Field("passenger_ids", r.PassengerIDs, validate.NotEmpty[string]()),
+		validate.Each("passenger_ids", r.PassengerIDs, validate.Required[string](), validate.UUID()),
+		validate.Field("segments", r.Segments, validate.NotEmpty[SegmentReq]()),
+		validate.Field("additional_info", r.AdditionalInfo, validate.MaxLen(additionalInfoMaxLen)),
+	}
+	for i, segment := range r.Segments {
+		field := fmt.Sprintf("segments[%d]", i)
+		checks = append(checks,
+			validate.Field(field+".flight_id", segment.FlightID, validate.Required[string](), validate.MaxLen(idMaxLen)),
+			validate.Field(field+".seats", segment.Seats, seatsFor(len(r.PassengerIDs))),
+			validate.Each(field+".seats", segment.Seats, validate.MaxLen(seatNumberMaxLen)),
+		)
+	}
+	return validate.All(checks...)
+}
+
+// seatsFor rejects seats of a segment unless there are none or one for each of passengers.
+func seatsFor(passengers int) validate.Rule[[]string] {
+	return func(seats []string) string {
+		if len(seats) != 0 && len(seats) != passengers {
+			return "must be given for every passenger of the segment"
+		}
+		return ""
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"flightticketservice/pkg/apperr"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,7 +15,7 @@ func TestCreateNewTicket(t *testing.T) {
 	flightID := "flight1"
 	passengerID := "passenger1"
 	seatNumber := "12A"
-	status := "V"
+	status := StatusHeld
 	additionalInfo := "booked"
 
 	ticket := CreateNewTicket(
@@ -34,3 +36,29 @@ func TestCreateNewTicket(t *testing.T) {
 	assert.Equal(t, departureTime, ticket.DepartureTime)
 	assert.Equal(t, arrivalTime, ticket.ArrivalTime)
 }
+
+func TestCreateBookingReqValidate(t *testing.T) {
+	req := &CreateBookingReq{
+		PassengerIDs: []string{"123e4567-e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-426614174001"},
+		Segments: []SegmentReq{
+			{FlightID: "10", Seats: []string{"1A", "1B"}},
+			{FlightID: "11"},
+		},
+	}
+	assert.NoError(t, req.Validate())
+
+	req.PassengerIDs = []string{"123e4567-e89b-12d3-a456-426614174000", "1"}
+	req.Segments[1].Seats = []string{"2A"}
+	err := req.Validate()
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	assert.Equal(t, []apperr.FieldError{
+		{Field: "passenger_ids[1]", Message: "must be a UUID"},
+		{Field: "segments[1].seats", Message: "must be given for every passenger of the segment"},
+	}, apperr.Fields(err))
+
+	err = (&CreateBookingReq{}).Validate()
+	assert.Equal(t, []apperr.FieldError{
+		{Field: "passenger_ids", Message: "must not be empty"},
+		{Field: "segments", Message: "must not be empty"},
+	}, apperr.Fields(err))
+}
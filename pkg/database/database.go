@@ -1,26 +1,44 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"flightticketservice/utils"
+	"database/sql/driver"
 	"fmt"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ConnectDB initializes and returns a database connection.
+// Every SQL statement run within a traced request gets its own span.
 func ConnectDB(host, user, password, dbname string) (*sql.DB, error) {
 	connStr := fmt.Sprintf("host=%s user=%s dbname=%s password=%s sslmode=disable", host, user, dbname, password)
-	db, err := sql.Open("postgres", connStr)
+	db, err := otelsql.Open("postgres", connStr,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBNamespace(dbname)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			SpanFilter:           withParentSpan,
+		}),
+	)
 	if err != nil {
-		utils.ErrorLog.Fatal("Failed to open a DB connection: ", err)
-		return nil, err
+		return nil, fmt.Errorf("open DB connection: %w", err)
 	}
 
 	if err := db.Ping(); err != nil {
-		utils.ErrorLog.Fatal("Failed to ping DB: ", err)
-		return nil, err
+		db.Close()
+		return nil, fmt.Errorf("ping DB: %w", err)
 	}
 
 	return db, nil
 }
+
+// withParentSpan skips spans of statements run outside of a trace, like migrations,
+// so they don't start traces of their own.
+func withParentSpan(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+	return trace.SpanFromContext(ctx).SpanContext().IsValid()
+}
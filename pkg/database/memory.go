@@ -0,0 +1,32 @@
+package database
+
+import "sync"
+
+// WriteLock serializes writes of in-memory stores with their units of work, like a transaction keeps
+// rows it changed locked until it ends. Stores lock it around every write, while a unit of work holds it
+// until it ends and runs on views of the stores that do not lock it again.
+// So a failed unit of work is undone without losing writes of other callers.
+type WriteLock struct {
+	mu   *sync.Mutex
+	held bool // the lock is held by a unit of work of the store
+}
+
+// NewWriteLock creates lock shared by in-memory stores of one unit of work.
+func NewWriteLock() WriteLock {
+	return WriteLock{mu: &sync.Mutex{}}
+}
+
+// Lock locks writes, the returned func unlocks them. Zero and held locks do nothing.
+func (l WriteLock) Lock() (unlock func()) {
+	if l.mu == nil || l.held {
+		return func() {}
+	}
+	l.mu.Lock()
+	return l.mu.Unlock
+}
+
+// Hold locks writes for a unit of work until unlock is called, stores given the held lock write without locking.
+func (l WriteLock) Hold() (held WriteLock, unlock func()) {
+	l.mu.Lock()
+	return WriteLock{mu: l.mu, held: true}, l.mu.Unlock
+}
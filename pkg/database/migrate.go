@@ -0,0 +1,248 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the key of advisory lock held while migrations run,
+// so replicas starting at the same time don't migrate the schema twice.
+const migrationLock = 7_426_001
+
+// ErrUnknownVersion is returned when database schema is newer than known migrations.
+var ErrUnknownVersion = errors.New("database schema version is unknown")
+
+// Migration is a pair of up and down sql scripts of one schema version.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies embedded migrations and records them in schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+// NewMigrator initializes a new Migrator with migrations embedded into the binary,
+// applied and reverted migrations are logged to logger.
+func NewMigrator(db *sql.DB, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, logger: logger}, nil
+}
+
+// loadMigrations reads files named <version>_<name>.up.sql and <version>_<name>.down.sql.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction := strings.TrimSuffix(name, ".sql"), ""
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			base, direction = strings.TrimSuffix(base, ".up"), "up"
+		case strings.HasSuffix(base, ".down"):
+			base, direction = strings.TrimSuffix(base, ".down"), "down"
+		default:
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql suffix", name)
+		}
+
+		prefix, title, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", name, prefix)
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if m.Name != title {
+			return nil, fmt.Errorf("migration %s: version %d is already used by %s", name, version, m.Name)
+		}
+
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: both up and down scripts are required", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the version of the newest known migration.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns current version of the database schema, 0 if nothing is applied.
+func (m *Migrator) Version() (int, error) {
+	var version int
+	err := m.withLock(func(conn *sql.Conn) error {
+		var err error
+		version, err = currentVersion(conn)
+		return err
+	})
+	return version, err
+}
+
+// Up applies all pending migrations and returns the resulting schema version.
+func (m *Migrator) Up() (int, error) {
+	var version int
+	err := m.withLock(func(conn *sql.Conn) error {
+		var err error
+		if version, err = currentVersion(conn); err != nil {
+			return err
+		}
+		if version > m.Latest() {
+			return fmt.Errorf("%w: %d, latest migration is %d", ErrUnknownVersion, version, m.Latest())
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err := apply(conn, migration.Up,
+				`insert into schema_migrations (version, name) values ($1, $2)`, migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			m.logger.Info("applied migration", "version", migration.Version, "name", migration.Name)
+			version = migration.Version
+		}
+		return nil
+	})
+	return version, err
+}
+
+// Down reverts up to steps latest migrations and returns the resulting schema version.
+func (m *Migrator) Down(steps int) (int, error) {
+	var version int
+	err := m.withLock(func(conn *sql.Conn) error {
+		var err error
+		if version, err = currentVersion(conn); err != nil {
+			return err
+		}
+		if version > m.Latest() {
+			return fmt.Errorf("%w: %d, latest migration is %d", ErrUnknownVersion, version, m.Latest())
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if err := apply(conn, migration.Down,
+				`delete from schema_migrations where version = $1`, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			m.logger.Info("reverted migration", "version", migration.Version, "name", migration.Name)
+			steps--
+			version = 0
+			if i > 0 {
+				version = m.migrations[i-1].Version
+			}
+		}
+		return nil
+	})
+	return version, err
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+func (m *Migrator) withLock(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `select pg_advisory_lock($1)`, migrationLock); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, `select pg_advisory_unlock($1)`, migrationLock); err != nil {
+			m.logger.Error("failed to release migration lock", "error", err)
+		}
+	}()
+
+	query := `create table if not exists schema_migrations (
+		version integer primary key,
+		name varchar(100) not null,
+		applied_at timestamp not null default current_timestamp
+	)`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// currentVersion returns the highest applied migration.
+func currentVersion(conn *sql.Conn) (int, error) {
+	var version int
+	err := conn.QueryRowContext(context.Background(),
+		`select coalesce(max(version), 0) from schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// apply runs migration script and its bookkeeping statement in one transaction.
+func apply(conn *sql.Conn, script, record string, args ...any) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
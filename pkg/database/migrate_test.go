@@ -0,0 +1,55 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"flightticketservice/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrator, err := NewMigrator(nil, utils.DiscardLogger())
+	assert.NoError(t, err)
+
+	for i, migration := range migrator.migrations {
+		assert.Equal(t, i+1, migration.Version)
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+	assert.Equal(t, len(migrator.migrations), migrator.Latest())
+
+	// tickets reference flights and passengers, also in tables created before migrations
+	assert.Contains(t, migrator.migrations[0].Up, "FOREIGN KEY (flight_id) REFERENCES flights(id)")
+	assert.Contains(t, migrator.migrations[0].Up, "FOREIGN KEY (passenger_id) REFERENCES passengers(id)")
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_add_column.up.sql":     {Data: []byte("alter table t add column c int;")},
+		"m/0002_add_column.down.sql":   {Data: []byte("alter table t drop column c;")},
+		"m/0001_create_table.up.sql":   {Data: []byte("create table t (id int);")},
+		"m/0001_create_table.down.sql": {Data: []byte("drop table t;")},
+	}
+
+	migrations, err := loadMigrations(fsys, "m")
+	assert.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "create_table", Up: "create table t (id int);", Down: "drop table t;"},
+		{Version: 2, Name: "add_column", Up: "alter table t add column c int;", Down: "alter table t drop column c;"},
+	}, migrations)
+
+	fsys["m/0002_other.up.sql"] = &fstest.MapFile{Data: []byte("select 1;")}
+	_, err = loadMigrations(fsys, "m")
+	assert.Error(t, err)
+
+	delete(fsys, "m/0002_other.up.sql")
+	delete(fsys, "m/0002_add_column.down.sql")
+	_, err = loadMigrations(fsys, "m")
+	assert.Error(t, err)
+
+	fsys["m/init.sql"] = &fstest.MapFile{Data: []byte("select 1;")}
+	_, err = loadMigrations(fsys, "m")
+	assert.Error(t, err)
+}
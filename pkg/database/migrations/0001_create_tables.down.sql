@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS booking_flights;
+DROP TABLE IF EXISTS flights;
+DROP TABLE IF EXISTS passengers;
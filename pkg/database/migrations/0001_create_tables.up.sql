@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS passengers (
+    id SERIAL PRIMARY KEY,
+    first_name VARCHAR(30) NOT NULL,
+    last_name VARCHAR(30) NOT NULL,
+    email VARCHAR(30) UNIQUE NOT NULL,
+    password VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS flights (
+    id SERIAL PRIMARY KEY,
+    airline VARCHAR(30) NOT NULL,
+    origin VARCHAR(30) NOT NULL,
+    destination VARCHAR(30) NOT NULL,
+    departure TIMESTAMP NOT NULL,
+    arrival TIMESTAMP NOT NULL,
+    price REAL NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS booking_flights (
+    id SERIAL PRIMARY KEY,
+    flight_id INTEGER NOT NULL,
+    passenger_id INTEGER NOT NULL,
+    booking_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    departure_time TIMESTAMP NOT NULL,
+    arrival_time TIMESTAMP NOT NULL,
+    status VARCHAR(30) NOT NULL CHECK (status IN ('booked', 'cancelled', 'confirmed')),
+    seat_number VARCHAR(30),
+    additional_info VARCHAR(100)
+);
+
+-- tables created by Init methods of the stores before migrations are kept,
+-- their string ids of flights and passengers are converted to reference them
+ALTER TABLE booking_flights
+    ALTER COLUMN flight_id TYPE INTEGER USING flight_id::integer,
+    ALTER COLUMN passenger_id TYPE INTEGER USING passenger_id::integer,
+    ADD CONSTRAINT booking_flights_flight_id_fkey FOREIGN KEY (flight_id) REFERENCES flights(id),
+    ADD CONSTRAINT booking_flights_passenger_id_fkey FOREIGN KEY (passenger_id) REFERENCES passengers(id);
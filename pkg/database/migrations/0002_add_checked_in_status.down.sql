@@ -0,0 +1,3 @@
+ALTER TABLE booking_flights DROP CONSTRAINT IF EXISTS booking_flights_status_check;
+ALTER TABLE booking_flights ADD CONSTRAINT booking_flights_status_check
+    CHECK (status IN ('booked', 'cancelled', 'confirmed'));
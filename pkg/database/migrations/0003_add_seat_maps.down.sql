@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS booking_flights_active_seat_idx;
+
+ALTER TABLE flights DROP COLUMN IF EXISTS seat_map;
@@ -0,0 +1,5 @@
+ALTER TABLE flights ADD COLUMN IF NOT EXISTS seat_map JSONB;
+
+CREATE UNIQUE INDEX IF NOT EXISTS booking_flights_active_seat_idx
+    ON booking_flights (flight_id, seat_number)
+    WHERE status <> 'cancelled' AND seat_number <> '';
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE flights DROP COLUMN IF EXISTS capacity;
@@ -0,0 +1,2 @@
+-- 174 is the number of seats in the default seat map used by flights without seat_map
+ALTER TABLE flights ADD COLUMN IF NOT EXISTS capacity INTEGER NOT NULL DEFAULT 174;
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE booking_flights DROP CONSTRAINT IF EXISTS booking_flights_status_check;
+ALTER TABLE booking_flights ADD CONSTRAINT booking_flights_status_check
+    CHECK (status IN ('booked', 'cancelled', 'confirmed', 'checked_in'));
+
+DROP INDEX IF EXISTS booking_flights_active_seat_idx;
+CREATE UNIQUE INDEX booking_flights_active_seat_idx
+    ON booking_flights (flight_id, seat_number)
+    WHERE status <> 'cancelled' AND seat_number <> '';
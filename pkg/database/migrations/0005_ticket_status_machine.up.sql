@@ -0,0 +1,8 @@
+ALTER TABLE booking_flights DROP CONSTRAINT IF EXISTS booking_flights_status_check;
+ALTER TABLE booking_flights ADD CONSTRAINT booking_flights_status_check
+    CHECK (status IN ('held', 'booked', 'confirmed', 'checked_in', 'boarded', 'cancelled', 'refunded', 'no_show'));
+
+DROP INDEX IF EXISTS booking_flights_active_seat_idx;
+CREATE UNIQUE INDEX booking_flights_active_seat_idx
+    ON booking_flights (flight_id, seat_number)
+    WHERE status NOT IN ('cancelled', 'refunded', 'no_show') AND seat_number <> '';
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS booking_flights_held_idx;
+
+ALTER TABLE booking_flights DROP COLUMN IF EXISTS hold_expires_at;
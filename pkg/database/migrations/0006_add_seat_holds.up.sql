@@ -0,0 +1,5 @@
+ALTER TABLE booking_flights ADD COLUMN IF NOT EXISTS hold_expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS booking_flights_held_idx
+    ON booking_flights (hold_expires_at)
+    WHERE status = 'held';
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS booking_flights_locator_idx;
+
+ALTER TABLE booking_flights DROP COLUMN IF EXISTS locator;
+
+DROP TABLE IF EXISTS booking_records;
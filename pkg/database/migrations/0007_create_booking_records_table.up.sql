@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS booking_records (
+    locator VARCHAR(6) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE booking_flights ADD COLUMN IF NOT EXISTS locator VARCHAR(6) REFERENCES booking_records(locator);
+
+CREATE INDEX IF NOT EXISTS booking_flights_locator_idx ON booking_flights (locator);
\ No newline at end of file
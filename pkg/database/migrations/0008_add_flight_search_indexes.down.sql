@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS flights_airline_departure_idx;
+DROP INDEX IF EXISTS flights_departure_idx;
+DROP INDEX IF EXISTS flights_route_departure_idx;
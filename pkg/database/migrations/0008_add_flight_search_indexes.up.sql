@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS flights_route_departure_idx ON flights (origin, destination, departure);
+CREATE INDEX IF NOT EXISTS flights_departure_idx ON flights (departure);
+CREATE INDEX IF NOT EXISTS flights_airline_departure_idx ON flights (airline, departure);
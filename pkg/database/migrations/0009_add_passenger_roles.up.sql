@@ -0,0 +1,3 @@
+-- existing accounts become passengers, agents and admins are promoted by an admin
+ALTER TABLE passengers ADD COLUMN IF NOT EXISTS role VARCHAR(10) NOT NULL DEFAULT 'passenger'
+    CHECK (role IN ('passenger', 'agent', 'admin'));
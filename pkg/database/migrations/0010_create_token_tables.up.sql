@@ -0,0 +1,18 @@
+-- refresh tokens are stored as sha256 hashes, tokens of one login share session_id
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    session_id VARCHAR(32) NOT NULL,
+    passenger_id VARCHAR(10) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_idx ON refresh_tokens (session_id);
+
+-- access tokens revoked before they expire, rows are deleted after expires_at
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    token_id VARCHAR(32) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,19 @@
+-- passengers get serial ids again, refresh tokens of deleted passengers are dropped
+DROP INDEX IF EXISTS passengers_created_at_idx;
+
+ALTER TABLE passengers ADD COLUMN serial_id SERIAL;
+
+ALTER TABLE booking_flights DROP CONSTRAINT booking_flights_passenger_id_fkey;
+ALTER TABLE booking_flights ALTER COLUMN passenger_id TYPE VARCHAR(36);
+UPDATE booking_flights b SET passenger_id = p.serial_id::text FROM passengers p WHERE b.passenger_id = p.id::text;
+ALTER TABLE booking_flights ALTER COLUMN passenger_id TYPE INTEGER USING passenger_id::integer;
+
+DELETE FROM refresh_tokens r WHERE NOT EXISTS (SELECT 1 FROM passengers p WHERE r.passenger_id = p.id::text);
+UPDATE refresh_tokens r SET passenger_id = p.serial_id::text FROM passengers p WHERE r.passenger_id = p.id::text;
+ALTER TABLE refresh_tokens ALTER COLUMN passenger_id TYPE VARCHAR(10);
+
+ALTER TABLE passengers DROP CONSTRAINT passengers_pkey;
+ALTER TABLE passengers DROP COLUMN id;
+ALTER TABLE passengers RENAME COLUMN serial_id TO id;
+ALTER TABLE passengers ADD PRIMARY KEY (id);
+ALTER TABLE booking_flights ADD CONSTRAINT booking_flights_passenger_id_fkey FOREIGN KEY (passenger_id) REFERENCES passengers(id);
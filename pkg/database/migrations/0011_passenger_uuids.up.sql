@@ -0,0 +1,20 @@
+-- passengers are identified by random UUIDs instead of serial numbers,
+-- every existing passenger gets one and references to the old ids are rewritten
+ALTER TABLE passengers ADD COLUMN IF NOT EXISTS uuid UUID NOT NULL DEFAULT gen_random_uuid();
+
+ALTER TABLE booking_flights DROP CONSTRAINT booking_flights_passenger_id_fkey;
+ALTER TABLE booking_flights ALTER COLUMN passenger_id TYPE VARCHAR(36);
+UPDATE booking_flights b SET passenger_id = p.uuid::text FROM passengers p WHERE b.passenger_id = p.id::text;
+ALTER TABLE booking_flights ALTER COLUMN passenger_id TYPE UUID USING passenger_id::uuid;
+
+ALTER TABLE refresh_tokens ALTER COLUMN passenger_id TYPE VARCHAR(36);
+UPDATE refresh_tokens r SET passenger_id = p.uuid::text FROM passengers p WHERE r.passenger_id = p.id::text;
+
+ALTER TABLE passengers DROP CONSTRAINT passengers_pkey;
+ALTER TABLE passengers DROP COLUMN id;
+ALTER TABLE passengers RENAME COLUMN uuid TO id;
+ALTER TABLE passengers ADD PRIMARY KEY (id);
+ALTER TABLE booking_flights ADD CONSTRAINT booking_flights_passenger_id_fkey FOREIGN KEY (passenger_id) REFERENCES passengers(id);
+
+-- ids are random, passengers are listed in order of registration by default
+CREATE INDEX IF NOT EXISTS passengers_created_at_idx ON passengers (created_at, id);
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaxTxAttempts is how many times a transaction is run before its conflict error is returned.
+const MaxTxAttempts = 3
+
+// txRetryDelay is the pause before the second attempt, it doubles with each next one.
+const txRetryDelay = 10 * time.Millisecond
+
+// Querier runs queries, it is implemented by both *sql.DB and *sql.Tx,
+// so a store bound to a transaction participates in it.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// RunInTx runs fn in a serializable transaction and commits it if fn succeeds.
+// The whole transaction is retried when postgres aborts it because of a conflict with a concurrent one.
+// If q is already a transaction fn runs inside it under a savepoint, the outer transaction
+// owns commit and retries.
+func RunInTx(ctx context.Context, q Querier, fn func(tx *sql.Tx) error) error {
+	switch q := q.(type) {
+	case *sql.Tx:
+		return runNested(ctx, q, fn)
+	case *sql.DB:
+		var err error
+		for attempt := 1; attempt <= MaxTxAttempts; attempt++ {
+			if attempt > 1 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(txRetryDelay << (attempt - 2)):
+				}
+			}
+			if err = runTx(ctx, q, fn); !IsConflict(err) {
+				return err
+			}
+		}
+		return err
+	default:
+		return errors.New("database: transactions are not supported by the querier")
+	}
+}
+
+// runTx runs one attempt of a transaction.
+func runTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// runNested runs fn under a savepoint, so its failure doesn't abort the outer transaction.
+func runNested(ctx context.Context, tx *sql.Tx, fn func(tx *sql.Tx) error) error {
+	if _, err := tx.ExecContext(ctx, `savepoint nested_tx`); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, `rollback to savepoint nested_tx`); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `release savepoint nested_tx`)
+	return err
+}
+
+// IsConflict reports if transaction was aborted because of serialization failure or deadlock
+// and can be run again.
+func IsConflict(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopQuerier struct {
+	Querier
+}
+
+func TestRunInTxRequiresTransactions(t *testing.T) {
+	called := false
+	err := RunInTx(context.Background(), nopQuerier{}, func(tx *sql.Tx) error {
+		called = true
+		return nil
+	})
+	assert.Error(t, err)
+	assert.False(t, called)
+}
+
+func TestIsConflict(t *testing.T) {
+	assert.True(t, IsConflict(&pq.Error{Code: "40001"}))
+	assert.True(t, IsConflict(fmt.Errorf("book seat: %w", &pq.Error{Code: "40P01"})))
+	assert.False(t, IsConflict(&pq.Error{Code: "23505"}))
+	assert.False(t, IsConflict(sql.ErrNoRows))
+	assert.False(t, IsConflict(nil))
+}
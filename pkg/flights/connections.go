@@ -0,0 +1,148 @@
+package flights
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Connection search defaults and limits.
+const (
+	DefaultMinConnection = 45 * time.Minute
+	DefaultMaxLayover    = 12 * time.Hour
+	MaxStops             = 2
+)
+
+// Sort orders of connection search.
+const (
+	SortByDuration = "duration"
+	SortByPrice    = "price"
+)
+
+// ConnectionParams collects parameters for searching flights with stops.
+type ConnectionParams struct {
+	Origin        string
+	Destination   string
+	DepartureDate time.Time
+	MaxStops      int
+	MinConnection time.Duration
+	MaxLayover    time.Duration
+	SortBy        string
+	Limit         int
+}
+
+// Validate checks connection search parameters and fills defaults.
+func (p *ConnectionParams) Validate() error {
+	if p.Origin == "" || p.Destination == "" {
+		return errors.New("origin and destination are required")
+	}
+	if p.Origin == p.Destination {
+		return errors.New("origin and destination must differ")
+	}
+	if p.DepartureDate.IsZero() {
+		return errors.New("departure date is required")
+	}
+	if p.MaxStops < 0 || p.MaxStops > MaxStops {
+		return fmt.Errorf("max stops must be from 0 to %d", MaxStops)
+	}
+
+	if p.MinConnection == 0 {
+		p.MinConnection = DefaultMinConnection
+	}
+	if p.MaxLayover == 0 {
+		p.MaxLayover = DefaultMaxLayover
+	}
+	if p.MinConnection < 0 || p.MaxLayover < p.MinConnection {
+		return errors.New("max layover must not be shorter than min connection time")
+	}
+
+	switch p.SortBy {
+	case "":
+		p.SortBy = SortByDuration
+	case SortByDuration, SortByPrice:
+	default:
+		return fmt.Errorf("unknown sort %q, use %q or %q", p.SortBy, SortByDuration, SortByPrice)
+	}
+
+	if p.Limit <= 0 {
+		p.Limit = DefaultItineraryLimit
+	}
+	p.Limit = min(p.Limit, MaxItineraryLimit)
+
+	return nil
+}
+
+// SearchConnections chains flights through intermediate airports from origin to destination.
+// Every connection leaves at least MinConnection and at most MaxLayover after the previous arrival.
+func SearchConnections(ctx context.Context, service FlightService, params ConnectionParams) ([]*Itinerary, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	itineraries := []*Itinerary{}
+	visited := map[string]bool{params.Origin: true}
+	path := []*Flight{}
+
+	// only flights of the window are queried: the search day for the first leg,
+	// the layover after the previous arrival for connections
+	var search func(window SearchParams) error
+	search = func(window SearchParams) error {
+		flights, err := service.GetFlightsByParams(ctx, window)
+		if err != nil && !errors.Is(err, ErrNoFlightsFound) {
+			return err
+		}
+
+		for _, flight := range flights {
+			if flight.Available <= 0 || visited[flight.Destination] {
+				continue
+			}
+
+			path = append(path, flight)
+			if flight.Destination == params.Destination {
+				itinerary := newItinerary(path)
+				itinerary.Stops = len(path) - 1
+				itineraries = append(itineraries, itinerary)
+			} else if len(path) <= params.MaxStops {
+				visited[flight.Destination] = true
+				err := search(SearchParams{
+					Origin:        flight.Destination,
+					DepartureFrom: flight.Arrival.Add(params.MinConnection),
+					DepartureTo:   flight.Arrival.Add(params.MaxLayover),
+				})
+				if err != nil {
+					return err
+				}
+				visited[flight.Destination] = false
+			}
+			path = path[:len(path)-1]
+		}
+		return nil
+	}
+
+	if err := search(SearchParams{Origin: params.Origin, DepartureDate: params.DepartureDate}); err != nil {
+		return nil, err
+	}
+
+	sortItineraries(itineraries, params.SortBy)
+	if len(itineraries) > params.Limit {
+		itineraries = itineraries[:params.Limit]
+	}
+
+	return itineraries, nil
+}
+
+// sortItineraries orders itineraries by total duration or price, the other one breaks ties.
+func sortItineraries(itineraries []*Itinerary, sortBy string) {
+	sort.SliceStable(itineraries, func(i, j int) bool {
+		a, b := itineraries[i], itineraries[j]
+		if sortBy == SortByPrice && a.TotalPrice != b.TotalPrice {
+			return a.TotalPrice < b.TotalPrice
+		}
+		if a.DurationMinutes != b.DurationMinutes {
+			return a.DurationMinutes < b.DurationMinutes
+		}
+		return a.TotalPrice < b.TotalPrice
+	})
+}
@@ -0,0 +1,99 @@
+package flights
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// searchedFlights serves flights matching search params and records the searches.
+type searchedFlights struct {
+	FlightService
+	flights  []*Flight
+	searches []SearchParams
+}
+
+func (s *searchedFlights) GetFlightsByParams(_ context.Context, params SearchParams) ([]*Flight, error) {
+	s.searches = append(s.searches, params)
+	found := []*Flight{}
+	for _, flight := range s.flights {
+		if params.Match(flight) {
+			found = append(found, flight)
+		}
+	}
+	if len(found) == 0 {
+		return nil, ErrNoFlightsFound
+	}
+	return found, nil
+}
+
+func TestConnectionParamsValidate(t *testing.T) {
+	day := time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC)
+
+	params := ConnectionParams{Origin: "MOW", Destination: "NYC", DepartureDate: day, MaxStops: 1}
+	assert.NoError(t, params.Validate())
+	assert.Equal(t, DefaultMinConnection, params.MinConnection)
+	assert.Equal(t, DefaultMaxLayover, params.MaxLayover)
+	assert.Equal(t, SortByDuration, params.SortBy)
+	assert.Equal(t, DefaultItineraryLimit, params.Limit)
+
+	tooManyStops := ConnectionParams{Origin: "MOW", Destination: "NYC", DepartureDate: day, MaxStops: MaxStops + 1}
+	assert.Error(t, tooManyStops.Validate())
+
+	badLayover := ConnectionParams{Origin: "MOW", Destination: "NYC", DepartureDate: day, MaxLayover: time.Minute}
+	assert.Error(t, badLayover.Validate())
+
+	badSort := ConnectionParams{Origin: "MOW", Destination: "NYC", DepartureDate: day, SortBy: "airline"}
+	assert.Error(t, badSort.Validate())
+}
+
+func TestSearchConnections(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, 11, 3, 8, 0, 0, 0, time.UTC)
+	service := &searchedFlights{flights: []*Flight{
+		testFlight("1", "MOW", "NYC", day, 11, 900),
+		testFlight("2", "MOW", "PAR", day, 4, 200),
+		testFlight("3", "PAR", "NYC", day.Add(6*time.Hour), 8, 300),
+		testFlight("4", "PAR", "NYC", day.Add(4*time.Hour+10*time.Minute), 8, 400), // too short connection
+		testFlight("5", "MOW", "BER", day.Add(-time.Hour), 2, 100),
+		testFlight("6", "BER", "PAR", day.Add(2*time.Hour+45*time.Minute), 1, 50),
+		testFlight("7", "PAR", "MOW", day.Add(5*time.Hour), 4, 10),    // back to origin
+		testFlight("8", "MOW", "NYC", day.Add(24*time.Hour), 11, 500), // next day
+	}}
+
+	direct, err := SearchConnections(ctx, service, ConnectionParams{Origin: "MOW", Destination: "NYC", DepartureDate: day})
+	assert.NoError(t, err)
+	assert.Len(t, direct, 1)
+	assert.Equal(t, []SearchParams{{Origin: "MOW", DepartureDate: day}}, service.searches, "only flights of the day are queried")
+	assert.Equal(t, "1", direct[0].Legs[0].ID)
+	assert.Equal(t, 0, direct[0].Stops)
+	assert.Equal(t, 11*60, direct[0].DurationMinutes)
+
+	service.searches = nil
+	byDuration, err := SearchConnections(ctx, service, ConnectionParams{Origin: "MOW", Destination: "NYC", DepartureDate: day, MaxStops: 2})
+	assert.NoError(t, err)
+	// connections from PAR are queried within the layover after arrival of flight 2
+	assert.Contains(t, service.searches, SearchParams{
+		Origin:        "PAR",
+		DepartureFrom: day.Add(4*time.Hour + DefaultMinConnection),
+		DepartureTo:   day.Add(4*time.Hour + DefaultMaxLayover),
+	})
+	assert.Len(t, byDuration, 3)
+	assert.Equal(t, "1", byDuration[0].Legs[0].ID)
+	assert.Equal(t, 1, byDuration[1].Stops)
+	assert.Equal(t, 500.0, byDuration[1].TotalPrice)
+	assert.Equal(t, 2, byDuration[2].Stops)
+
+	byPrice, err := SearchConnections(ctx, service, ConnectionParams{Origin: "MOW", Destination: "NYC", DepartureDate: day, MaxStops: 2, SortBy: SortByPrice})
+	assert.NoError(t, err)
+	assert.Len(t, byPrice, 3)
+	assert.Equal(t, 450.0, byPrice[0].TotalPrice)
+	assert.Equal(t, []string{"5", "6", "3"}, []string{byPrice[0].Legs[0].ID, byPrice[0].Legs[1].ID, byPrice[0].Legs[2].ID})
+	assert.Equal(t, 900.0, byPrice[2].TotalPrice)
+
+	none, err := SearchConnections(ctx, service, ConnectionParams{Origin: "MOW", Destination: "LON", DepartureDate: day, MaxStops: 2})
+	assert.NoError(t, err)
+	assert.Empty(t, none)
+}
@@ -1,50 +1,80 @@
 package flights
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
-	"fmt"
+	"strconv"
+	"time"
+
+	"flightticketservice/pkg/apperr"
+	"flightticketservice/pkg/database"
+	"flightticketservice/pkg/pagination"
+	"flightticketservice/utils"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer starts spans of store methods, statements they run are traced by the database driver.
+var tracer = otel.Tracer("flightticketservice/pkg/flights")
+
 // FlightService interface for working with flights.
 type FlightService interface {
-	GetFlights() ([]*Flight, error)
-	GetFlightsByParams(params SearchParams) ([]*Flight, error)
-	GetFlightByID(flightID string) (*Flight, error)
-	CreateFlight(*Flight) error
-	UpdateFlight(id string, newFlight *Flight) error
-	DeleteFlight(flightID string) error
+	GetFlights(ctx context.Context, params pagination.Params) (*pagination.Page[*Flight], error)
+	GetFlightsByParams(ctx context.Context, params SearchParams) ([]*Flight, error)
+	GetFlightByID(ctx context.Context, flightID string) (*Flight, error)
+	CreateFlight(ctx context.Context, flight *Flight) error
+	UpdateFlight(ctx context.Context, id string, newFlight *Flight) error
+	DeleteFlight(ctx context.Context, flightID string) error
 }
 
-// FlightsStore structure implements interface FlightService.
-type FlightsStore struct {
-	db *sql.DB
-}
+// Flight store errors.
+var (
+	ErrNoFlightsFound = apperr.New(apperr.ErrNotFound, "no_flights_found", "no flights found matching the search criteria")
+	ErrFlightNotFound = apperr.New(apperr.ErrNotFound, "flight_not_found", "flight not found")
+	ErrFlightBooked   = apperr.New(apperr.ErrConflict, "flight_booked", "flight has tickets")
+)
 
-// NewFlightsStore initializes a new PostgresStore with a shared database connection.
-func NewFlightsStore(db *sql.DB) *FlightsStore {
-	return &FlightsStore{db: db}
+// flightColumns selects flights together with active tickets and seats they hold.
+const flightColumns = `f.id, f.airline, f.origin, f.destination, f.departure, f.arrival, f.price, f.seat_map, f.capacity,
+	(select count(*) from booking_flights b
+		where b.flight_id = f.id and b.status not in ('cancelled', 'refunded', 'no_show')),
+	array(select b.seat_number from booking_flights b
+		where b.flight_id = f.id and b.status not in ('cancelled', 'refunded', 'no_show') and b.seat_number <> '')`
+
+// selectFlights selects all flights.
+const selectFlights = `select ` + flightColumns + ` from flights f`
+
+// listFlights describes sort keys and filters of flights list.
+var listFlights = &pagination.Query{
+	Columns: flightColumns,
+	From:    "flights f",
+	ID:      pagination.Column{Expr: "f.id", Type: "int"},
+	Sorts: map[string]pagination.Column{
+		"id":        {Expr: "f.id", Type: "int"},
+		"departure": {Expr: "f.departure", Type: "timestamp"},
+		"arrival":   {Expr: "f.arrival", Type: "timestamp"},
+		"price":     {Expr: "f.price", Type: "real"},
+	},
+	DefaultSort: "id",
+	Filters: map[string]pagination.Column{
+		"origin":      {Expr: "f.origin"},
+		"destination": {Expr: "f.destination"},
+		"airline":     {Expr: "f.airline"},
+	},
 }
 
-// Init initializes db with data
-func (fs *FlightsStore) Init() error {
-	return fs.CreateFlightsTable()
+// FlightsStore structure implements interface FlightService.
+type FlightsStore struct {
+	db database.Querier
 }
 
-// CreateFlightsTable creates flights table in db
-func (fs *FlightsStore) CreateFlightsTable() error {
-	query := `create table if not exists flights (
-		ID serial primary key,
-		airline varchar(30),
-		origin  varchar(30),
-		destination varchar(30),
-		departure timestamp,
-		arrival timestamp,
-		price real
-	)`
-
-	_, err := fs.db.Exec(query)
-	return err
+// NewFlightsStore creates flight store on db, the connection pool or a transaction of a unit of work.
+func NewFlightsStore(db database.Querier) *FlightsStore {
+	return &FlightsStore{db: db}
 }
 
 // CreateFlight creates flight in table
@@ -57,25 +87,36 @@ func (fs *FlightsStore) CreateFlightsTable() error {
 // @Success 200 "Flight created"
 // @Failure 400 "Invalid flight data"
 // @Router /api/v1/flights/create [post]
-func (fs *FlightsStore) CreateFlight(fl *Flight) error {
+func (fs *FlightsStore) CreateFlight(ctx context.Context, fl *Flight) (err error) {
+	ctx, span := tracer.Start(ctx, "FlightsStore.CreateFlight")
+	defer func() { utils.EndSpan(span, err) }()
+
+	seatMap, err := marshalSeatMap(fl.SeatMap)
+	if err != nil {
+		return err
+	}
+
 	query := `insert into flights
-	(airline, origin, destination, departure, arrival, price)
-	values ($1, $2, $3, $4, $5, $6)`
+	(airline, origin, destination, departure, arrival, price, seat_map, capacity)
+	values ($1, $2, $3, $4, $5, $6, $7, $8)
+	returning id`
 
-	resp, err := fs.db.Query(
+	err = fs.db.QueryRowContext(
+		ctx,
 		query,
 		fl.Airline,
 		fl.Origin,
 		fl.Destination,
 		fl.Departure,
 		fl.Arrival,
-		fl.Price)
-
+		fl.Price,
+		seatMap,
+		fl.Capacity).Scan(&fl.ID)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%+v\n", resp)
+	span.SetAttributes(utils.FlightIDKey.String(fl.ID))
 	return nil
 }
 
@@ -90,17 +131,30 @@ func (fs *FlightsStore) CreateFlight(fl *Flight) error {
 // @Success 200 "Flight updated"
 // @Failure 404 "Flight not found"
 // @Router /api/v1/flights/{id}/update [post]
-func (fs *FlightsStore) UpdateFlight(id string, newFlight *Flight) error {
+func (fs *FlightsStore) UpdateFlight(ctx context.Context, id string, newFlight *Flight) (err error) {
+	ctx, span := tracer.Start(ctx, "FlightsStore.UpdateFlight", trace.WithAttributes(utils.FlightIDKey.String(id)))
+	defer func() { utils.EndSpan(span, err) }()
 
 	if newFlight == nil {
 		return errors.New("update request is nil")
 	}
 
+	seatMap, err := marshalSeatMap(newFlight.SeatMap)
+	if err != nil {
+		return err
+	}
+
+	if !validID(id) {
+		return ErrFlightNotFound
+	}
+
 	query := `UPDATE flights SET
-	airline = $1, origin = $2, destination = $3, departure = $4, arrival = $5, price = $6
-	WHERE id = $7`
+	airline = $1, origin = $2, destination = $3, departure = $4, arrival = $5, price = $6,
+	seat_map = $7, capacity = $8
+	WHERE id = $9`
 
-	_, err := fs.db.Query(
+	res, err := fs.db.ExecContext(
+		ctx,
 		query,
 		newFlight.Airline,
 		newFlight.Origin,
@@ -108,13 +162,15 @@ func (fs *FlightsStore) UpdateFlight(id string, newFlight *Flight) error {
 		newFlight.Departure,
 		newFlight.Arrival,
 		newFlight.Price,
+		seatMap,
+		newFlight.Capacity,
 		id)
 
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return notFound(ctx, res)
 }
 
 // DeleteFlight deletes flight from db
@@ -126,43 +182,105 @@ func (fs *FlightsStore) UpdateFlight(id string, newFlight *Flight) error {
 // @Param id path string true "Unique identifier of the flight"
 // @Success 200 "Passenger deleted"
 // @Failure 404 "Passenger not found"
+// @Failure 409 "Flight has tickets"
 // @Router /api/v1/flights/{id}/delete [delete]
-func (fs *FlightsStore) DeleteFlight(id string) error {
-	_, err := fs.db.Query("delete from flights where id = $1", id)
+func (fs *FlightsStore) DeleteFlight(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "FlightsStore.DeleteFlight", trace.WithAttributes(utils.FlightIDKey.String(id)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	if !validID(id) {
+		return ErrFlightNotFound
+	}
+
+	res, err := fs.db.ExecContext(ctx, "delete from flights where id = $1", id)
+	if err != nil {
+		return ticketsError(err)
+	}
+
+	return notFound(ctx, res)
+}
+
+// validID reports if id is a serial number, other ids match no flight and are not sent to the integer column.
+func validID(id string) bool {
+	_, err := strconv.ParseInt(id, 10, 32)
+	return err == nil
+}
+
+// ticketsError converts violation of the foreign key of tickets to ErrFlightBooked.
+func ticketsError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "booking_flights_flight_id_fkey" {
+		return ErrFlightBooked
+	}
 	return err
 }
 
-// GetFlights returns all flights
+// notFound returns ErrFlightNotFound if statement affected no rows.
+func notFound(ctx context.Context, res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	utils.SetRows(ctx, rowsAffected)
+
+	if rowsAffected == 0 {
+		return ErrFlightNotFound
+	}
+
+	return nil
+}
+
+// GetFlights returns page of flights
 // @Summary Get list of flights
-// @Description get flights
+// @Description get flights page by page
 // @Tags flights
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} Flight
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort key, prefix with - for descending" Enums(id, -id, departure, -departure, arrival, -arrival, price, -price)
+// @Param origin query string false "Origin of the flight"
+// @Param destination query string false "Destination of the flight"
+// @Param airline query string false "Airline of the flight"
+// @Success 200 {object} pagination.Page[flights.Flight]
+// @Failure 400 "Invalid pagination parameters or unknown filter"
 // @Router /api/v1/flights [get]
-func (fs *FlightsStore) GetFlights() ([]*Flight, error) {
-	rows, err := fs.db.Query("select * from flights")
+func (fs *FlightsStore) GetFlights(ctx context.Context, params pagination.Params) (page *pagination.Page[*Flight], err error) {
+	ctx, span := tracer.Start(ctx, "FlightsStore.GetFlights")
+	defer func() { utils.EndSpan(span, err) }()
+
+	page, err = pagination.Fetch(ctx, fs.db, listFlights, params, scanFlight, flightField)
 	if err != nil {
 		return nil, err
 	}
 
-	passengers := []*Flight{}
-	for rows.Next() {
-		passenger, err := scanFlight(rows)
-
-		if err != nil {
-			return nil, err
-		}
+	utils.SetRows(ctx, int64(len(page.Items)))
+	return page, nil
+}
 
-		passengers = append(passengers, passenger)
+// flightField returns flight field by sort key or filter name of listFlights.
+func flightField(flight *Flight, name string) string {
+	switch name {
+	case "departure":
+		return flight.Departure.Format(time.RFC3339Nano)
+	case "arrival":
+		return flight.Arrival.Format(time.RFC3339Nano)
+	case "price":
+		return strconv.FormatFloat(flight.Price, 'g', -1, 64)
+	case "origin":
+		return flight.Origin
+	case "destination":
+		return flight.Destination
+	case "airline":
+		return flight.Airline
 	}
-
-	return passengers, nil
+	return flight.ID
 }
 
 // GetFlightsByParams returns list of flights queries by params
 // @Summary Search flights by parameters
 // @Description Retrieves a list of flights filtered by the provided search parameters.
+// @Description With max_stops it returns connecting itineraries from origin to destination instead, departing on departure_date.
 // @Tags flights
 // @Accept json
 // @Produce json
@@ -170,14 +288,36 @@ func (fs *FlightsStore) GetFlights() ([]*Flight, error) {
 // @Param destination query string false "Destination location of the flight"
 // @Param departure query string false "Departure date and time"
 // @Param arrival query string false "Arrival date and time"
-// @Success 200 {array} Flight
+// @Param airline query string false "Airline of the flight"
+// @Param departure_date query string false "Departure date, YYYY-MM-DD"
+// @Param flex_days query int false "Also match departures this many days before and after departure_date, up to 7"
+// @Param departure_time_from query string false "Earliest departure time of day, HH:MM"
+// @Param departure_time_to query string false "Latest departure time of day, HH:MM"
+// @Param min_price query number false "Minimum price"
+// @Param max_price query number false "Maximum price"
+// @Param max_stops query int false "Maximum number of stops, from 0 to 2"
+// @Param min_connection query string false "Minimum connection time, e.g. 45m"
+// @Param max_layover query string false "Maximum layover, e.g. 12h"
+// @Param sort query string false "Sort flights by departure, arrival, price or duration; itineraries by duration or price" Enums(departure, arrival, price, duration)
+// @Param limit query int false "Maximum number of itineraries"
+// @Success 200 {array} Flight "Flights, or flights.Itinerary list when max_stops is set"
+// @Failure 400 "Invalid search parameters"
 // @Failure 404 "No flights found matching the search criteria"
 // @Router /api/v1/flights/search [get]
-func (fs *FlightsStore) GetFlightsByParams(params SearchParams) ([]*Flight, error) {
-	rows, err := fs.db.Query("select * from flights")
+func (fs *FlightsStore) GetFlightsByParams(ctx context.Context, params SearchParams) (_ []*Flight, err error) {
+	ctx, span := tracer.Start(ctx, "FlightsStore.GetFlightsByParams")
+	defer func() { utils.EndSpan(span, err) }()
+
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	query, args := searchQuery(params)
+	rows, err := fs.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	flights := []*Flight{}
 	for rows.Next() {
@@ -187,23 +327,15 @@ func (fs *FlightsStore) GetFlightsByParams(params SearchParams) ([]*Flight, erro
 			return nil, err
 		}
 
-		if params.Origin != "" && flight.Origin != params.Origin {
-			continue
-		}
-		if params.Destination != "" && flight.Destination != params.Destination {
-			continue
-		}
-		if !params.Departure.IsZero() && !flight.Departure.Equal(params.Departure) {
-			continue
-		}
-		if !params.Arrival.IsZero() && !flight.Arrival.Equal(params.Arrival) {
-			continue
-		}
 		flights = append(flights, flight)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	utils.SetRows(ctx, int64(len(flights)))
 	if len(flights) == 0 {
-		return nil, errors.New("no flights found matching the search criteria")
+		return nil, ErrNoFlightsFound
 	}
 
 	return flights, nil
@@ -219,21 +351,34 @@ func (fs *FlightsStore) GetFlightsByParams(params SearchParams) ([]*Flight, erro
 // @Success 200 {object} Flight
 // @Failure 404 "Flight not found"
 // @Router /api/v1/flights/{id} [get]
-func (fs *FlightsStore) GetFlightByID(flightID string) (*Flight, error) {
-	rows, err := fs.db.Query("select * from flights where id = $1", flightID)
+func (fs *FlightsStore) GetFlightByID(ctx context.Context, flightID string) (_ *Flight, err error) {
+	ctx, span := tracer.Start(ctx, "FlightsStore.GetFlightByID", trace.WithAttributes(utils.FlightIDKey.String(flightID)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	if !validID(flightID) {
+		return nil, ErrFlightNotFound
+	}
+
+	rows, err := fs.db.QueryContext(ctx, selectFlights+" where f.id = $1", flightID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		return scanFlight(rows)
 	}
 
-	return nil, errors.New("flight not found")
+	return nil, ErrFlightNotFound
 }
 
 func scanFlight(rows *sql.Rows) (*Flight, error) {
 	flight := new(Flight)
+	var (
+		seatMap    []byte
+		booked     int
+		takenSeats []string
+	)
 	err := rows.Scan(
 		&flight.ID,
 		&flight.Airline,
@@ -241,7 +386,29 @@ func scanFlight(rows *sql.Rows) (*Flight, error) {
 		&flight.Destination,
 		&flight.Departure,
 		&flight.Arrival,
-		&flight.Price)
+		&flight.Price,
+		&seatMap,
+		&flight.Capacity,
+		&booked,
+		pq.Array(&takenSeats))
+	if err != nil {
+		return nil, err
+	}
 
-	return flight, err
+	flight.SeatMap = DefaultSeatMap()
+	if seatMap != nil {
+		if err := json.Unmarshal(seatMap, flight.SeatMap); err != nil {
+			return nil, err
+		}
+	}
+
+	flight.SetAvailability(booked, takenSeats)
+	return flight, nil
+}
+
+func marshalSeatMap(seatMap *SeatMap) ([]byte, error) {
+	if seatMap == nil {
+		return nil, nil
+	}
+	return json.Marshal(seatMap)
 }
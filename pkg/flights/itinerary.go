@@ -0,0 +1,192 @@
+package flights
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Itinerary search limits.
+const (
+	MaxItineraryLegs      = 6
+	DefaultItineraryLimit = 20
+	MaxItineraryLimit     = 100
+)
+
+// itineraryLegCandidates is how many cheapest flights per leg are combined.
+const itineraryLegCandidates = 50
+
+// itineraryDateLayout is the format of leg departure date.
+const itineraryDateLayout = "2006-01-02"
+
+// ItineraryReq collects legs of round trip or multi-city search.
+type ItineraryReq struct {
+	Legs  []LegReq `json:"legs"`
+	Limit int      `json:"limit,omitempty"`
+}
+
+// LegReq collects route and departure date of one itinerary leg.
+type LegReq struct {
+	Origin      string `json:"origin"`
+	Destination string `json:"destination"`
+	Date        string `json:"date"` // departure date, YYYY-MM-DD
+}
+
+// Itinerary is a priced combination of flights, one per requested leg or connection.
+type Itinerary struct {
+	Legs            []*Flight `json:"legs"`
+	TotalPrice      float64   `json:"total_price"`
+	DurationMinutes int       `json:"duration_minutes"` // from first departure to last arrival
+	Stops           int       `json:"stops,omitempty"`  // intermediate airports of connection search
+}
+
+// newItinerary copies flights to a new itinerary and computes its price and duration.
+func newItinerary(flights []*Flight) *Itinerary {
+	itinerary := &Itinerary{Legs: append([]*Flight{}, flights...)}
+	for _, flight := range flights {
+		itinerary.TotalPrice += flight.Price
+	}
+	if len(flights) > 0 {
+		duration := flights[len(flights)-1].Arrival.Sub(flights[0].Departure)
+		itinerary.DurationMinutes = int(duration / time.Minute)
+	}
+	return itinerary
+}
+
+// SearchParams converts itinerary request legs to flight search parameters.
+func (req *ItineraryReq) SearchParams() ([]SearchParams, error) {
+	if len(req.Legs) == 0 || len(req.Legs) > MaxItineraryLegs {
+		return nil, fmt.Errorf("itinerary must have from 1 to %d legs", MaxItineraryLegs)
+	}
+
+	params := make([]SearchParams, 0, len(req.Legs))
+	for i, leg := range req.Legs {
+		if leg.Origin == "" || leg.Destination == "" {
+			return nil, fmt.Errorf("leg %d: origin and destination are required", i+1)
+		}
+
+		date, err := time.Parse(itineraryDateLayout, leg.Date)
+		if err != nil {
+			return nil, fmt.Errorf("leg %d: invalid date %q, use YYYY-MM-DD", i+1, leg.Date)
+		}
+		if i > 0 && date.Before(params[i-1].DepartureDate) {
+			return nil, fmt.Errorf("leg %d departs before leg %d", i+1, i)
+		}
+
+		params = append(params, SearchParams{
+			Origin:        leg.Origin,
+			Destination:   leg.Destination,
+			DepartureDate: date,
+		})
+	}
+
+	return params, nil
+}
+
+// SearchItineraries finds flights for every leg and returns cheapest combinations first.
+func SearchItineraries(ctx context.Context, service FlightService, req *ItineraryReq) ([]*Itinerary, error) {
+	params, err := req.SearchParams()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultItineraryLimit
+	}
+	limit = min(limit, MaxItineraryLimit)
+
+	legs := make([][]*Flight, 0, len(params))
+	for _, leg := range params {
+		flights, err := service.GetFlightsByParams(ctx, leg)
+		if err != nil && !errors.Is(err, ErrNoFlightsFound) {
+			return nil, err
+		}
+		legs = append(legs, flights)
+	}
+
+	return CombineItineraries(legs, limit), nil
+}
+
+// CombineItineraries picks one flight per leg so that every flight departs after the
+// previous one arrives, and returns up to limit cheapest combinations.
+// Sold out flights are skipped.
+func CombineItineraries(legs [][]*Flight, limit int) []*Itinerary {
+	if len(legs) == 0 || limit <= 0 {
+		return nil
+	}
+
+	candidates := make([][]*Flight, len(legs))
+	for i, flights := range legs {
+		for _, flight := range flights {
+			if flight.Available > 0 {
+				candidates[i] = append(candidates[i], flight)
+			}
+		}
+		if len(candidates[i]) == 0 {
+			return []*Itinerary{}
+		}
+
+		sort.SliceStable(candidates[i], func(a, b int) bool {
+			return candidates[i][a].Price < candidates[i][b].Price
+		})
+		if len(candidates[i]) > itineraryLegCandidates {
+			candidates[i] = candidates[i][:itineraryLegCandidates]
+		}
+	}
+
+	// cheapestRest[i] is the lowest possible price of legs i..n-1, used to prune combinations.
+	cheapestRest := make([]float64, len(candidates)+1)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		cheapestRest[i] = cheapestRest[i+1] + candidates[i][0].Price
+	}
+
+	best := []*Itinerary{}
+	path := make([]*Flight, 0, len(candidates))
+
+	var combine func(leg int, price float64)
+	combine = func(leg int, price float64) {
+		if leg == len(candidates) {
+			best = insertItinerary(best, newItinerary(path), limit)
+			return
+		}
+
+		for _, flight := range candidates[leg] {
+			total := price + flight.Price
+			if len(best) == limit && total+cheapestRest[leg+1] >= best[limit-1].TotalPrice {
+				break // candidates are sorted by price, the rest are not cheaper
+			}
+			if leg > 0 && !flight.Departure.After(path[leg-1].Arrival) {
+				continue
+			}
+
+			path = append(path, flight)
+			combine(leg+1, total)
+			path = path[:leg]
+		}
+	}
+	combine(0, 0)
+
+	return best
+}
+
+// insertItinerary keeps itineraries sorted by total price and at most limit long.
+func insertItinerary(itineraries []*Itinerary, itinerary *Itinerary, limit int) []*Itinerary {
+	i := sort.Search(len(itineraries), func(i int) bool {
+		return itineraries[i].TotalPrice > itinerary.TotalPrice
+	})
+	if i >= limit {
+		return itineraries
+	}
+
+	itineraries = append(itineraries, nil)
+	copy(itineraries[i+1:], itineraries[i:])
+	itineraries[i] = itinerary
+
+	if len(itineraries) > limit {
+		itineraries = itineraries[:limit]
+	}
+	return itineraries
+}
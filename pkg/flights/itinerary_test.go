@@ -0,0 +1,76 @@
+package flights
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFlight(id, origin, destination string, departure time.Time, hours int, price float64) *Flight {
+	flight := NewFlight("Aeroflot", origin, destination, departure, departure.Add(time.Duration(hours)*time.Hour), price)
+	flight.ID = id
+	flight.SetAvailability(0, nil)
+	return flight
+}
+
+func TestItineraryReqSearchParams(t *testing.T) {
+	req := &ItineraryReq{Legs: []LegReq{
+		{Origin: "MOW", Destination: "PAR", Date: "2026-11-03"},
+		{Origin: "PAR", Destination: "MOW", Date: "2026-11-10"},
+	}}
+
+	params, err := req.SearchParams()
+	assert.NoError(t, err)
+	assert.Len(t, params, 2)
+	assert.Equal(t, time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC), params[0].DepartureDate)
+	assert.Equal(t, "PAR", params[1].Origin)
+
+	req.Legs[1].Date = "2026-11-01"
+	_, err = req.SearchParams()
+	assert.Error(t, err)
+
+	req.Legs[1].Date = "10.11.2026"
+	_, err = req.SearchParams()
+	assert.Error(t, err)
+
+	_, err = (&ItineraryReq{}).SearchParams()
+	assert.Error(t, err)
+}
+
+func TestCombineItineraries(t *testing.T) {
+	day := time.Date(2026, 11, 3, 8, 0, 0, 0, time.UTC)
+	outbound := []*Flight{
+		testFlight("1", "MOW", "PAR", day, 4, 300),
+		testFlight("2", "MOW", "PAR", day.Add(6*time.Hour), 4, 100),
+	}
+	inbound := []*Flight{
+		testFlight("3", "PAR", "MOW", day.Add(5*time.Hour), 4, 50),
+		testFlight("4", "PAR", "MOW", day.Add(24*time.Hour), 4, 200),
+	}
+
+	itineraries := CombineItineraries([][]*Flight{outbound, inbound}, 10)
+	assert.Len(t, itineraries, 3)
+
+	assert.Equal(t, 300.0, itineraries[0].TotalPrice)
+	assert.Equal(t, "2", itineraries[0].Legs[0].ID)
+	assert.Equal(t, "4", itineraries[0].Legs[1].ID)
+
+	assert.Equal(t, 350.0, itineraries[1].TotalPrice)
+	assert.Equal(t, "3", itineraries[1].Legs[1].ID)
+
+	assert.Equal(t, 500.0, itineraries[2].TotalPrice)
+
+	limited := CombineItineraries([][]*Flight{outbound, inbound}, 1)
+	assert.Len(t, limited, 1)
+	assert.Equal(t, 300.0, limited[0].TotalPrice)
+}
+
+func TestCombineItinerariesSkipsSoldOut(t *testing.T) {
+	day := time.Date(2026, 11, 3, 8, 0, 0, 0, time.UTC)
+	soldOut := testFlight("1", "MOW", "PAR", day, 4, 100)
+	soldOut.SetAvailability(soldOut.Capacity, nil)
+
+	itineraries := CombineItineraries([][]*Flight{{soldOut}}, 10)
+	assert.Empty(t, itineraries)
+}
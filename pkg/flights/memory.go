@@ -0,0 +1,210 @@
+package flights
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+
+	"flightticketservice/pkg/database"
+	"flightticketservice/pkg/pagination"
+)
+
+// Occupancy returns number of active tickets and seats they hold on the flight.
+type Occupancy func(flightID string) (booked int, takenSeats []string)
+
+// MemoryStore keeps flights in memory, it implements FlightService without a database.
+type MemoryStore struct {
+	*memoryData
+	writes database.WriteLock
+}
+
+// memoryData is state of a store shared with its views.
+type memoryData struct {
+	mu        sync.RWMutex
+	lastID    int
+	flights   map[string]*Flight
+	occupancy Occupancy
+}
+
+// NewMemoryStore creates empty in-memory flight store, all seats are free until SetOccupancy.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{memoryData: &memoryData{flights: map[string]*Flight{}}}
+}
+
+// WithWriteLock returns view of the store sharing its flights that locks writes with lock.
+func (ms *MemoryStore) WithWriteLock(lock database.WriteLock) *MemoryStore {
+	return &MemoryStore{memoryData: ms.memoryData, writes: lock}
+}
+
+// SetOccupancy sets source of booked seats used for flight availability.
+// It must not call back into the flight store.
+func (ms *MemoryStore) SetOccupancy(occupancy Occupancy) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.occupancy = occupancy
+}
+
+// CreateFlight stores flight and sets its ID
+func (ms *MemoryStore) CreateFlight(ctx context.Context, fl *Flight) error {
+	defer ms.writes.Lock()()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.lastID++
+	fl.ID = strconv.Itoa(ms.lastID)
+	ms.flights[fl.ID] = cloneFlight(fl)
+	return nil
+}
+
+// UpdateFlight replaces flight data by id
+func (ms *MemoryStore) UpdateFlight(ctx context.Context, id string, newFlight *Flight) error {
+	defer ms.writes.Lock()()
+
+	if newFlight == nil {
+		return errors.New("update request is nil")
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.flights[id]; !ok {
+		return ErrFlightNotFound
+	}
+
+	stored := cloneFlight(newFlight)
+	stored.ID = id
+	ms.flights[id] = stored
+	return nil
+}
+
+// DeleteFlight deletes flight by id
+func (ms *MemoryStore) DeleteFlight(ctx context.Context, id string) error {
+	defer ms.writes.Lock()()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.flights[id]; !ok {
+		return ErrFlightNotFound
+	}
+
+	delete(ms.flights, id)
+	return nil
+}
+
+// GetFlightByID returns flight by id
+func (ms *MemoryStore) GetFlightByID(ctx context.Context, flightID string) (*Flight, error) {
+	ms.mu.RLock()
+	flight, ok := ms.flights[flightID]
+	if ok {
+		flight = cloneFlight(flight)
+	}
+	occupancy := ms.occupancy
+	ms.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrFlightNotFound
+	}
+
+	setOccupancy(flight, occupancy)
+	return flight, nil
+}
+
+// GetFlights returns page of flights
+func (ms *MemoryStore) GetFlights(ctx context.Context, params pagination.Params) (*pagination.Page[*Flight], error) {
+	flights, occupancy := ms.all()
+
+	page, err := pagination.Slice(listFlights, params, flights, flightField)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, flight := range page.Items {
+		setOccupancy(flight, occupancy)
+	}
+	return page, nil
+}
+
+// GetFlightsByParams returns flights matching params
+func (ms *MemoryStore) GetFlightsByParams(ctx context.Context, params SearchParams) ([]*Flight, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	flights, occupancy := ms.all()
+	flights = slices.DeleteFunc(flights, func(flight *Flight) bool {
+		return !params.Match(flight)
+	})
+
+	if len(flights) == 0 {
+		return nil, ErrNoFlightsFound
+	}
+
+	sortFlights(flights, params.SortBy)
+	for _, flight := range flights {
+		setOccupancy(flight, occupancy)
+	}
+	return flights, nil
+}
+
+// all returns copies of all flights and occupancy to apply outside of the lock.
+func (ms *MemoryStore) all() ([]*Flight, Occupancy) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	flights := make([]*Flight, 0, len(ms.flights))
+	for _, flight := range ms.flights {
+		flights = append(flights, cloneFlight(flight))
+	}
+	return flights, ms.occupancy
+}
+
+// setOccupancy fills availability of the flight like scanFlight does.
+func setOccupancy(flight *Flight, occupancy Occupancy) {
+	if flight.SeatMap == nil {
+		flight.SeatMap = DefaultSeatMap()
+	}
+
+	var (
+		booked     int
+		takenSeats []string
+	)
+	if occupancy != nil {
+		booked, takenSeats = occupancy(flight.ID)
+	}
+	flight.SetAvailability(booked, takenSeats)
+}
+
+// cloneFlight copies flight so that stored flights are not shared with callers.
+func cloneFlight(flight *Flight) *Flight {
+	copied := *flight
+	if flight.SeatMap != nil {
+		seatMap := SeatMap{Cabins: slices.Clone(flight.SeatMap.Cabins)}
+		for i := range seatMap.Cabins {
+			seatMap.Cabins[i].ExitRows = slices.Clone(seatMap.Cabins[i].ExitRows)
+		}
+		copied.SeatMap = &seatMap
+	}
+	copied.AvailableByClass = nil
+	return &copied
+}
+
+// Snapshot copies flights of the store, the returned func puts the copy back.
+// IDs given out after the snapshot are not reused, like values of a sequence after a rollback.
+func (ms *MemoryStore) Snapshot() (restore func()) {
+	ms.mu.RLock()
+	flights := make(map[string]*Flight, len(ms.flights))
+	for id, flight := range ms.flights {
+		flights[id] = cloneFlight(flight)
+	}
+	ms.mu.RUnlock()
+
+	return func() {
+		ms.mu.Lock()
+		ms.flights = flights
+		ms.mu.Unlock()
+	}
+}
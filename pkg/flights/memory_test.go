@@ -0,0 +1,52 @@
+package flights
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flightticketservice/pkg/pagination"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	day := time.Date(2026, 11, 3, 8, 0, 0, 0, time.UTC)
+
+	morning := NewFlight("Aeroflot", "MOW", "PAR", day, day.Add(4*time.Hour), 300)
+	evening := NewFlight("Aeroflot", "MOW", "PAR", day.Add(10*time.Hour), day.Add(14*time.Hour), 200)
+	assert.NoError(t, store.CreateFlight(ctx, morning))
+	assert.NoError(t, store.CreateFlight(ctx, evening))
+	assert.Equal(t, "1", morning.ID)
+
+	store.SetOccupancy(func(flightID string) (int, []string) {
+		if flightID == morning.ID {
+			return 2, []string{"1A"}
+		}
+		return 0, nil
+	})
+
+	flight, err := store.GetFlightByID(ctx, morning.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, morning.Capacity-2, flight.Available)
+
+	found, err := store.GetFlightsByParams(ctx, SearchParams{Origin: "MOW", DepartureDate: day, SortBy: SortByPrice})
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, evening.ID, found[0].ID)
+
+	_, err = store.GetFlightsByParams(ctx, SearchParams{Origin: "MOW", DepartureDate: day, DepartureTimeTo: 6 * time.Hour})
+	assert.ErrorIs(t, err, ErrNoFlightsFound)
+
+	page, err := store.GetFlights(ctx, pagination.Params{Sort: "-price"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, morning.ID, page.Items[0].ID)
+
+	assert.ErrorIs(t, store.UpdateFlight(ctx, "42", evening), ErrFlightNotFound)
+	assert.NoError(t, store.DeleteFlight(ctx, evening.ID))
+	_, err = store.GetFlightByID(ctx, evening.ID)
+	assert.ErrorIs(t, err, ErrFlightNotFound)
+}
@@ -0,0 +1,185 @@
+package flights
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxFlexDays is the widest flexibility around departure date.
+const MaxFlexDays = 7
+
+// Sort keys of flight search.
+const (
+	SortByDeparture = "departure"
+	SortByArrival   = "arrival"
+)
+
+// searchOrders maps sort keys to order by clauses, id keeps the order stable.
+var searchOrders = map[string]string{
+	SortByDeparture: "f.departure, f.id",
+	SortByArrival:   "f.arrival, f.id",
+	SortByPrice:     "f.price, f.departure, f.id",
+	SortByDuration:  "f.arrival - f.departure, f.departure, f.id",
+}
+
+// Validate checks search parameters that cannot be combined or are out of range.
+func (p *SearchParams) Validate() error {
+	if p.FlexDays < 0 || p.FlexDays > MaxFlexDays {
+		return fmt.Errorf("flex days must be from 0 to %d", MaxFlexDays)
+	}
+	if p.FlexDays > 0 && p.DepartureDate.IsZero() {
+		return errors.New("flex days require departure date")
+	}
+	if p.DepartureTimeFrom < 0 || p.DepartureTimeFrom >= 24*time.Hour ||
+		p.DepartureTimeTo < 0 || p.DepartureTimeTo >= 24*time.Hour {
+		return errors.New("departure time of day must be from 00:00 to 23:59")
+	}
+	if p.DepartureTimeTo != 0 && p.DepartureTimeTo < p.DepartureTimeFrom {
+		return errors.New("departure time range ends before it starts")
+	}
+	if !p.DepartureTo.IsZero() && p.DepartureTo.Before(p.DepartureFrom) {
+		return errors.New("departure window ends before it starts")
+	}
+	if p.MinPrice < 0 || p.MaxPrice < 0 {
+		return errors.New("price bounds must not be negative")
+	}
+	if p.MaxPrice != 0 && p.MaxPrice < p.MinPrice {
+		return errors.New("max price is lower than min price")
+	}
+	if _, ok := searchOrders[p.SortBy]; p.SortBy != "" && !ok {
+		return fmt.Errorf("unknown sort %q", p.SortBy)
+	}
+	return nil
+}
+
+// searchQuery builds parameterized query selecting flights that match params.
+func searchQuery(params SearchParams) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if params.Origin != "" {
+		where("f.origin = $%d", params.Origin)
+	}
+	if params.Destination != "" {
+		where("f.destination = $%d", params.Destination)
+	}
+	if params.Airline != "" {
+		where("f.airline = $%d", params.Airline)
+	}
+	if !params.Departure.IsZero() {
+		where("f.departure = $%d", params.Departure.UTC())
+	}
+	if !params.Arrival.IsZero() {
+		where("f.arrival = $%d", params.Arrival.UTC())
+	}
+	if !params.DepartureDate.IsZero() {
+		date := params.DepartureDate.UTC().Truncate(24 * time.Hour)
+		flex := params.FlexDays
+		where("f.departure >= $%d", date.AddDate(0, 0, -flex))
+		where("f.departure < $%d", date.AddDate(0, 0, flex+1))
+	}
+	if !params.DepartureFrom.IsZero() {
+		where("f.departure >= $%d", params.DepartureFrom.UTC())
+	}
+	if !params.DepartureTo.IsZero() {
+		where("f.departure <= $%d", params.DepartureTo.UTC())
+	}
+	if params.DepartureTimeFrom != 0 {
+		where("f.departure::time >= $%d::time", timeOfDay(params.DepartureTimeFrom))
+	}
+	if params.DepartureTimeTo != 0 {
+		where("f.departure::time <= $%d::time", timeOfDay(params.DepartureTimeTo))
+	}
+	if params.MinPrice != 0 {
+		where("f.price >= $%d", params.MinPrice)
+	}
+	if params.MaxPrice != 0 {
+		where("f.price <= $%d", params.MaxPrice)
+	}
+
+	query := selectFlights
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+
+	order, ok := searchOrders[params.SortBy]
+	if !ok {
+		order = searchOrders[SortByDeparture]
+	}
+	return query + " order by " + order, args
+}
+
+// timeOfDay formats duration since midnight as postgres time literal.
+func timeOfDay(d time.Duration) string {
+	return time.Time{}.Add(d).Format(time.TimeOnly)
+}
+
+// Match reports if flight matches params the same way searchQuery does.
+func (p *SearchParams) Match(flight *Flight) bool {
+	if p.Origin != "" && flight.Origin != p.Origin ||
+		p.Destination != "" && flight.Destination != p.Destination ||
+		p.Airline != "" && flight.Airline != p.Airline {
+		return false
+	}
+	if !p.Departure.IsZero() && !flight.Departure.Equal(p.Departure) ||
+		!p.Arrival.IsZero() && !flight.Arrival.Equal(p.Arrival) {
+		return false
+	}
+
+	departure := flight.Departure.UTC()
+	if !p.DepartureDate.IsZero() {
+		date := p.DepartureDate.UTC().Truncate(24 * time.Hour)
+		if departure.Before(date.AddDate(0, 0, -p.FlexDays)) || !departure.Before(date.AddDate(0, 0, p.FlexDays+1)) {
+			return false
+		}
+	}
+	if !p.DepartureFrom.IsZero() && departure.Before(p.DepartureFrom) ||
+		!p.DepartureTo.IsZero() && departure.After(p.DepartureTo) {
+		return false
+	}
+
+	clock := departure.Sub(departure.Truncate(24 * time.Hour))
+	if p.DepartureTimeFrom != 0 && clock < p.DepartureTimeFrom ||
+		p.DepartureTimeTo != 0 && clock > p.DepartureTimeTo {
+		return false
+	}
+
+	if p.MinPrice != 0 && flight.Price < p.MinPrice ||
+		p.MaxPrice != 0 && flight.Price > p.MaxPrice {
+		return false
+	}
+
+	return true
+}
+
+// sortFlights orders flights by sort key like searchOrders, ids are compared as numbers.
+func sortFlights(flights []*Flight, sortBy string) {
+	key := func(flight *Flight) []float64 {
+		id, _ := strconv.ParseFloat(flight.ID, 64)
+		departure := float64(flight.Departure.UnixNano())
+		switch sortBy {
+		case SortByArrival:
+			return []float64{float64(flight.Arrival.UnixNano()), id}
+		case SortByPrice:
+			return []float64{flight.Price, departure, id}
+		case SortByDuration:
+			return []float64{float64(flight.Arrival.Sub(flight.Departure)), departure, id}
+		}
+		return []float64{departure, id}
+	}
+
+	sort.SliceStable(flights, func(i, j int) bool {
+		return slices.Compare(key(flights[i]), key(flights[j])) < 0
+	})
+}
@@ -0,0 +1,56 @@
+package flights
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchParamsValidate(t *testing.T) {
+	day := time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC)
+
+	valid := SearchParams{DepartureDate: day, FlexDays: 3, DepartureTimeFrom: 6 * time.Hour, DepartureTimeTo: 12 * time.Hour, MaxPrice: 500, SortBy: SortByPrice}
+	assert.NoError(t, valid.Validate())
+
+	assert.Error(t, (&SearchParams{FlexDays: 1}).Validate())
+	assert.Error(t, (&SearchParams{DepartureDate: day, FlexDays: MaxFlexDays + 1}).Validate())
+	assert.Error(t, (&SearchParams{DepartureTimeFrom: 24 * time.Hour}).Validate())
+	assert.Error(t, (&SearchParams{DepartureTimeFrom: 12 * time.Hour, DepartureTimeTo: 6 * time.Hour}).Validate())
+	assert.Error(t, (&SearchParams{MinPrice: 300, MaxPrice: 100}).Validate())
+	assert.Error(t, (&SearchParams{DepartureFrom: day, DepartureTo: day.Add(-time.Hour)}).Validate())
+	assert.Error(t, (&SearchParams{SortBy: "airline"}).Validate())
+}
+
+func TestSearchQuery(t *testing.T) {
+	query, args := searchQuery(SearchParams{})
+	assert.NotContains(t, query, " where ")
+	assert.True(t, strings.HasSuffix(query, " order by f.departure, f.id"))
+	assert.Empty(t, args)
+
+	day := time.Date(2026, 11, 3, 15, 30, 0, 0, time.UTC)
+	query, args = searchQuery(SearchParams{
+		Origin:            "MOW",
+		Airline:           "Aeroflot",
+		DepartureDate:     day,
+		FlexDays:          2,
+		DepartureTimeFrom: 6*time.Hour + 30*time.Minute,
+		MaxPrice:          500,
+		SortBy:            SortByPrice,
+	})
+	assert.Contains(t, query, " where f.origin = $1 and f.airline = $2 and f.departure >= $3 and f.departure < $4"+
+		" and f.departure::time >= $5::time and f.price <= $6 order by f.price")
+	assert.Equal(t, []any{
+		"MOW",
+		"Aeroflot",
+		time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 11, 6, 0, 0, 0, 0, time.UTC),
+		"06:30:00",
+		500.0,
+	}, args)
+
+	query, args = searchQuery(SearchParams{Origin: "PAR", DepartureFrom: day, DepartureTo: day.Add(12 * time.Hour)})
+	assert.Contains(t, query, " where f.origin = $1 and f.departure >= $2 and f.departure <= $3 order by")
+	assert.Equal(t, []any{"PAR", day, day.Add(12 * time.Hour)}, args)
+}
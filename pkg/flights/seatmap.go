@@ -0,0 +1,168 @@
+package flights
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"flightticketservice/pkg/apperr"
+)
+
+// ErrSeatNotFound is returned when seat is not present in the flight seat map.
+var ErrSeatNotFound = apperr.New(apperr.ErrValidation, "seat_not_found", "seat does not exist on this flight")
+
+// CabinLayout describes seat rows of one cabin class.
+type CabinLayout struct {
+	Class    string `json:"class"`
+	FirstRow int    `json:"first_row"`
+	LastRow  int    `json:"last_row"`
+	Layout   string `json:"layout"` // seat letters with aisles as spaces, e.g. "ABC DEF"
+	ExitRows []int  `json:"exit_rows,omitempty"`
+}
+
+// SeatMap describes cabin layout of the flight.
+type SeatMap struct {
+	Cabins []CabinLayout `json:"cabins"`
+}
+
+// Seat collects seat attributes.
+type Seat struct {
+	Number  string `json:"number"`
+	Row     int    `json:"row"`
+	Letter  string `json:"letter"`
+	Class   string `json:"class"`
+	Window  bool   `json:"window"`
+	Aisle   bool   `json:"aisle"`
+	ExitRow bool   `json:"exit_row"`
+}
+
+// SeatAvailability shows if seat can be taken.
+type SeatAvailability struct {
+	Seat
+	Available bool `json:"available"`
+}
+
+// DefaultSeatMap returns narrow-body layout used when flight has no seat map.
+func DefaultSeatMap() *SeatMap {
+	return &SeatMap{
+		Cabins: []CabinLayout{
+			{Class: "business", FirstRow: 1, LastRow: 3, Layout: "AC DF"},
+			{Class: "economy", FirstRow: 4, LastRow: 30, Layout: "ABC DEF", ExitRows: []int{12, 13}},
+		},
+	}
+}
+
+// Validate checks that seat map is consistent.
+func (m *SeatMap) Validate() error {
+	if m == nil || len(m.Cabins) == 0 {
+		return errors.New("seat map must have at least one cabin")
+	}
+
+	rows := map[int]bool{}
+	for _, cabin := range m.Cabins {
+		if cabin.Class == "" {
+			return errors.New("cabin class cannot be empty")
+		}
+		if cabin.FirstRow < 1 || cabin.LastRow < cabin.FirstRow {
+			return fmt.Errorf("invalid rows %d-%d in %s cabin", cabin.FirstRow, cabin.LastRow, cabin.Class)
+		}
+
+		letters := map[rune]bool{}
+		for _, letter := range cabin.Layout {
+			if letter == ' ' {
+				continue
+			}
+			if letter < 'A' || letter > 'Z' || letters[letter] {
+				return fmt.Errorf("invalid layout %q in %s cabin", cabin.Layout, cabin.Class)
+			}
+			letters[letter] = true
+		}
+		if len(letters) == 0 {
+			return fmt.Errorf("empty layout in %s cabin", cabin.Class)
+		}
+
+		for row := cabin.FirstRow; row <= cabin.LastRow; row++ {
+			if rows[row] {
+				return fmt.Errorf("row %d belongs to several cabins", row)
+			}
+			rows[row] = true
+		}
+	}
+
+	return nil
+}
+
+// Seats returns all seats of the seat map ordered by row and letter.
+func (m *SeatMap) Seats() []Seat {
+	if m == nil {
+		return nil
+	}
+
+	seats := []Seat{}
+	for _, cabin := range m.Cabins {
+		layout := []rune(cabin.Layout)
+		exit := map[int]bool{}
+		for _, row := range cabin.ExitRows {
+			exit[row] = true
+		}
+
+		for row := cabin.FirstRow; row <= cabin.LastRow; row++ {
+			for i, letter := range layout {
+				if letter == ' ' {
+					continue
+				}
+				seats = append(seats, Seat{
+					Number:  strconv.Itoa(row) + string(letter),
+					Row:     row,
+					Letter:  string(letter),
+					Class:   cabin.Class,
+					Window:  i == 0 || i == len(layout)-1,
+					Aisle:   (i > 0 && layout[i-1] == ' ') || (i < len(layout)-1 && layout[i+1] == ' '),
+					ExitRow: exit[row],
+				})
+			}
+		}
+	}
+
+	return seats
+}
+
+// Seat looks up seat by its number, e.g. "12A".
+func (m *SeatMap) Seat(number string) (Seat, bool) {
+	number = strings.ToUpper(strings.TrimSpace(number))
+	for _, seat := range m.Seats() {
+		if seat.Number == number {
+			return seat, true
+		}
+	}
+	return Seat{}, false
+}
+
+// Capacity returns number of seats per cabin class.
+func (m *SeatMap) Capacity() map[string]int {
+	capacity := map[string]int{}
+	for _, seat := range m.Seats() {
+		capacity[seat.Class]++
+	}
+	return capacity
+}
+
+// Availability marks seats taken by active tickets as unavailable.
+func (m *SeatMap) Availability(taken []string) []SeatAvailability {
+	occupied := map[string]bool{}
+	for _, number := range taken {
+		occupied[strings.ToUpper(number)] = true
+	}
+
+	seats := m.Seats()
+	availability := make([]SeatAvailability, 0, len(seats))
+	for _, seat := range seats {
+		availability = append(availability, SeatAvailability{
+			Seat:      seat,
+			Available: !occupied[seat.Number],
+		})
+	}
+
+	return availability
+}
@@ -0,0 +1,57 @@
+package flights
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeatMapSeats(t *testing.T) {
+	seatMap := &SeatMap{
+		Cabins: []CabinLayout{
+			{Class: "economy", FirstRow: 10, LastRow: 12, Layout: "ABC DEF", ExitRows: []int{11}},
+		},
+	}
+
+	assert.NoError(t, seatMap.Validate())
+	assert.Len(t, seatMap.Seats(), 18)
+
+	seat, ok := seatMap.Seat("11c")
+	assert.True(t, ok)
+	assert.Equal(t, "11C", seat.Number)
+	assert.True(t, seat.Aisle)
+	assert.False(t, seat.Window)
+	assert.True(t, seat.ExitRow)
+
+	seat, ok = seatMap.Seat("10F")
+	assert.True(t, ok)
+	assert.True(t, seat.Window)
+	assert.False(t, seat.ExitRow)
+
+	_, ok = seatMap.Seat("13A")
+	assert.False(t, ok)
+}
+
+func TestSeatMapValidate(t *testing.T) {
+	assert.NoError(t, DefaultSeatMap().Validate())
+	assert.Error(t, (&SeatMap{}).Validate())
+	assert.Error(t, (&SeatMap{Cabins: []CabinLayout{{Class: "economy", FirstRow: 5, LastRow: 4, Layout: "AB"}}}).Validate())
+	assert.Error(t, (&SeatMap{Cabins: []CabinLayout{{Class: "economy", FirstRow: 1, LastRow: 4, Layout: "AA"}}}).Validate())
+	assert.Error(t, (&SeatMap{Cabins: []CabinLayout{
+		{Class: "business", FirstRow: 1, LastRow: 4, Layout: "AB"},
+		{Class: "economy", FirstRow: 4, LastRow: 8, Layout: "AB"},
+	}}).Validate())
+}
+
+func TestSeatMapAvailability(t *testing.T) {
+	availability := DefaultSeatMap().Availability([]string{"1A", "12c"})
+
+	taken := 0
+	for _, seat := range availability {
+		if !seat.Available {
+			taken++
+			assert.Contains(t, []string{"1A", "12C"}, seat.Number)
+		}
+	}
+	assert.Equal(t, 2, taken)
+}
@@ -1,25 +1,47 @@
 package flights
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"flightticketservice/pkg/validate"
+)
+
+// nameMaxLen is length of airline, origin and destination columns of flights table.
+const nameMaxLen = 30
 
 // Flight collects flight data.
 // @Description Flight model for API response.
 type Flight struct {
-	ID          string    `json:"id"`
-	Airline     string    `json:"airline"`
-	Origin      string    `json:"origin"`
-	Destination string    `json:"destination"`
-	Departure   time.Time `json:"departure"`
-	Arrival     time.Time `json:"arrival"`
-	Price       float64   `json:"price"`
+	ID               string         `json:"id"`
+	Airline          string         `json:"airline"`
+	Origin           string         `json:"origin"`
+	Destination      string         `json:"destination"`
+	Departure        time.Time      `json:"departure"`
+	Arrival          time.Time      `json:"arrival"`
+	Price            float64        `json:"price"`
+	SeatMap          *SeatMap       `json:"seat_map,omitempty"`
+	Capacity         int            `json:"capacity"`
+	Available        int            `json:"available"` // seats left for booking
+	AvailableByClass map[string]int `json:"available_by_class,omitempty"`
 }
 
 // SearchParams collects parameters for searching flights.
 type SearchParams struct {
-	Origin      string
-	Destination string
-	Departure   time.Time
-	Arrival     time.Time
+	Origin            string
+	Destination       string
+	Airline           string
+	Departure         time.Time
+	Arrival           time.Time
+	DepartureDate     time.Time     // matches any departure on this UTC day
+	FlexDays          int           // widens DepartureDate by this many days both ways
+	DepartureTimeFrom time.Duration // earliest departure time of day, since midnight
+	DepartureTimeTo   time.Duration // latest departure time of day, since midnight
+	DepartureFrom     time.Time     // earliest departure, e.g. after a connection
+	DepartureTo       time.Time     // latest departure
+	MinPrice          float64
+	MaxPrice          float64
+	SortBy            string // departure, arrival, price or duration
 }
 
 // CreateFlightReq collects info about flight for request.
@@ -30,10 +52,28 @@ type CreateFlightReq struct {
 	Departure   time.Time `json:"departure"`
 	Arrival     time.Time `json:"arrival"`
 	Price       float64   `json:"price"`
+	SeatMap     *SeatMap  `json:"seat_map,omitempty"`
+	Capacity    int       `json:"capacity,omitempty"`
+}
+
+// Validate checks fields of the request, seat map and capacity are checked by ApplyLayout.
+func (r *CreateFlightReq) Validate() error {
+	return validate.All(
+		validate.Field("airline", r.Airline, validate.Required[string](), validate.MaxLen(nameMaxLen)),
+		validate.Field("origin", r.Origin, validate.Required[string](), validate.MaxLen(nameMaxLen)),
+		validate.Field("destination", r.Destination,
+			validate.Required[string](), validate.MaxLen(nameMaxLen), validate.NotEqualFold("origin", r.Origin)),
+		validate.Field("departure", r.Departure, validate.Required[time.Time]()),
+		validate.Field("arrival", r.Arrival, validate.Required[time.Time](), validate.After("departure", r.Departure)),
+		validate.Field("price", r.Price, validate.Positive[float64]()),
+		validate.Field("capacity", r.Capacity, validate.Min(0)),
+	)
 }
 
 // NewFlight creates new flight by passed params
 func NewFlight(airline, origin, destination string, departure, arrival time.Time, price float64) *Flight {
+	seatMap := DefaultSeatMap()
+
 	return &Flight{
 		Airline:     airline,
 		Origin:      origin,
@@ -41,5 +81,43 @@ func NewFlight(airline, origin, destination string, departure, arrival time.Time
 		Departure:   departure,
 		Arrival:     arrival,
 		Price:       price,
+		SeatMap:     seatMap,
+		Capacity:    len(seatMap.Seats()),
+	}
+}
+
+// ApplyLayout sets seat map and capacity of the flight, capacity defaults to number of seats.
+func (fl *Flight) ApplyLayout(seatMap *SeatMap, capacity int) error {
+	if seatMap != nil {
+		if err := seatMap.Validate(); err != nil {
+			return err
+		}
+		fl.SeatMap = seatMap
+	}
+
+	seats := len(fl.SeatMap.Seats())
+	if capacity == 0 {
+		capacity = seats
+	}
+	if capacity < 0 || capacity > seats {
+		return fmt.Errorf("capacity must be between 1 and %d seats", seats)
+	}
+
+	fl.Capacity = capacity
+	return nil
+}
+
+// SetAvailability calculates seats left from active tickets and seats they hold.
+func (fl *Flight) SetAvailability(booked int, takenSeats []string) {
+	fl.Available = max(fl.Capacity-booked, 0)
+
+	fl.AvailableByClass = fl.SeatMap.Capacity()
+	for _, number := range takenSeats {
+		if seat, ok := fl.SeatMap.Seat(number); ok {
+			fl.AvailableByClass[seat.Class]--
+		}
+	}
+	for class, available := range fl.AvailableByClass {
+		fl.AvailableByClass[class] = min(max(available, 0), fl.Available)
 	}
 }
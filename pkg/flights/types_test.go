@@ -1,9 +1,12 @@
 package flights
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"flightticketservice/pkg/apperr"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,3 +27,57 @@ func TestNewFlight(t *testing.T) {
 	assert.Equal(t, arrival, flight.Arrival)
 	assert.Equal(t, price, flight.Price)
 }
+
+func TestFlightApplyLayout(t *testing.T) {
+	flight := NewFlight("Aeroflot", "Москва", "Париж", time.Now(), time.Now().Add(time.Hour), 1000)
+	assert.Equal(t, 174, flight.Capacity)
+
+	seatMap := &SeatMap{Cabins: []CabinLayout{{Class: "economy", FirstRow: 1, LastRow: 10, Layout: "AB CD"}}}
+	assert.NoError(t, flight.ApplyLayout(seatMap, 0))
+	assert.Equal(t, 40, flight.Capacity)
+
+	assert.NoError(t, flight.ApplyLayout(nil, 30))
+	assert.Equal(t, 30, flight.Capacity)
+
+	assert.Error(t, flight.ApplyLayout(nil, 41))
+	assert.Error(t, flight.ApplyLayout(&SeatMap{}, 0))
+}
+
+func TestFlightSetAvailability(t *testing.T) {
+	flight := NewFlight("Aeroflot", "Москва", "Париж", time.Now(), time.Now().Add(time.Hour), 1000)
+
+	flight.SetAvailability(3, []string{"1A", "5B"})
+	assert.Equal(t, 171, flight.Available)
+	assert.Equal(t, 11, flight.AvailableByClass["business"])
+	assert.Equal(t, 161, flight.AvailableByClass["economy"])
+
+	flight.SetAvailability(174, nil)
+	assert.Equal(t, 0, flight.Available)
+	assert.Equal(t, 0, flight.AvailableByClass["economy"])
+}
+
+func TestCreateFlightReqValidate(t *testing.T) {
+	departure := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	req := &CreateFlightReq{
+		Airline:     "Aeroflot",
+		Origin:      "MOW",
+		Destination: "LED",
+		Departure:   departure,
+		Arrival:     departure.Add(90 * time.Minute),
+		Price:       120,
+	}
+	assert.NoError(t, req.Validate())
+
+	req.Airline = strings.Repeat("a", 31)
+	req.Destination = "mow"
+	req.Arrival = departure.Add(-time.Hour)
+	req.Price = -1
+	err := req.Validate()
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	assert.Equal(t, []apperr.FieldError{
+		{Field: "airline", Message: "must be at most 30 characters"},
+		{Field: "destination", Message: "must differ from origin"},
+		{Field: "arrival", Message: "must be after departure"},
+		{Field: "price", Message: "must be greater than 0"},
+	}, apperr.Fields(err))
+}
@@ -0,0 +1,90 @@
+package pagination
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Slice pages items in memory with the same sort keys, filters and cursors as Fetch.
+// field returns value of item field by sort key or filter name, "id" must return the ID column.
+func Slice[T any](q *Query, params Params, items []T, field func(item T, name string) string) (*Page[T], error) {
+	pos, err := q.parse(params)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]T, 0, len(items))
+	for _, item := range items {
+		if matches(params.Filters, item, field) {
+			matched = append(matched, item)
+		}
+	}
+
+	// compare orders items by sort key and id, descending sort reverses both.
+	compare := func(value, id string, other T) int {
+		c := compareValues(pos.column.Type, value, field(other, pos.key))
+		if c == 0 {
+			c = compareValues(q.ID.Type, id, field(other, "id"))
+		}
+		if pos.desc {
+			return -c
+		}
+		return c
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return compare(field(matched[i], pos.key), field(matched[i], "id"), matched[j]) < 0
+	})
+
+	page := &Page[T]{Items: []T{}, Total: len(matched)}
+	for _, item := range matched {
+		if pos.after != nil && compare(pos.after.Value, pos.after.ID, item) >= 0 {
+			continue
+		}
+		if len(page.Items) == pos.limit {
+			last := page.Items[pos.limit-1]
+			page.NextCursor = pos.nextCursor(field(last, pos.key), field(last, "id"))
+			break
+		}
+		page.Items = append(page.Items, item)
+	}
+
+	return page, nil
+}
+
+// matches reports if item has every filter value.
+func matches[T any](filters map[string]string, item T, field func(item T, name string) string) bool {
+	for name, value := range filters {
+		if field(item, name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// compareValues compares field values as postgres compares them after cast to typ.
+func compareValues(typ, a, b string) int {
+	switch typ {
+	case "int", "real":
+		x, errX := strconv.ParseFloat(a, 64)
+		y, errY := strconv.ParseFloat(b, 64)
+		if errX == nil && errY == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	case "timestamp":
+		x, errX := time.Parse(time.RFC3339Nano, a)
+		y, errY := time.Parse(time.RFC3339Nano, b)
+		if errX == nil && errY == nil {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(a, b)
+}
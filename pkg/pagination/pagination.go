@@ -0,0 +1,275 @@
+// Package pagination implements cursor based pagination, sorting and filtering of list queries.
+package pagination
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"flightticketservice/pkg/apperr"
+	"flightticketservice/pkg/database"
+)
+
+// Page size limits.
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// ErrInvalidParams is returned for unknown sort key, malformed cursor or negative limit.
+var ErrInvalidParams = apperr.New(apperr.ErrValidation, "invalid_pagination", "invalid pagination parameters")
+
+// Params collects page size, position and order of a list request.
+type Params struct {
+	Limit   int
+	Cursor  string            // next_cursor of the previous page, empty for the first page
+	Sort    string            // sort key, "-" prefix sorts descending
+	Filters map[string]string // exact match filters by field name
+}
+
+// Page is one page of list response.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
+
+// Column is a sortable or filterable sql expression.
+type Column struct {
+	Expr string // e.g. "f.departure"
+	Type string // postgres type cursor values are cast to, e.g. "timestamp"
+}
+
+// Query describes a paginated list over one table.
+type Query struct {
+	Columns     string            // selected columns
+	From        string            // table with optional alias
+	ID          Column            // unique column that breaks ties
+	Sorts       map[string]Column // allowed sort keys
+	DefaultSort string
+	Filters     map[string]Column // allowed filters
+}
+
+// cursor is position after the last item of a page.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// encodeCursor returns opaque cursor string.
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses cursor string made by encodeCursor.
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidParams)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidParams)
+	}
+	return c, nil
+}
+
+// Statements are sql statements of one page request.
+type Statements struct {
+	List      string // selects up to Limit+1 rows, the extra one tells that there is a next page
+	ListArgs  []any
+	Count     string // counts all rows that match filters
+	CountArgs []any
+	Limit     int
+	Sort      string // sort with direction prefix, cursor is valid only for it
+	Key       string // sort key without direction prefix
+}
+
+// position is a parsed and validated page request.
+type position struct {
+	limit  int
+	sort   string // sort with direction prefix
+	key    string // sort key without direction prefix
+	desc   bool
+	column Column
+	after  *cursor // nil for the first page
+}
+
+// parse validates params against allowed sort keys and filters.
+func (q *Query) parse(params Params) (*position, error) {
+	pos := &position{limit: params.Limit, sort: params.Sort}
+	if pos.limit < 0 {
+		return nil, fmt.Errorf("%w: limit must not be negative", ErrInvalidParams)
+	}
+	if pos.limit == 0 {
+		pos.limit = DefaultLimit
+	}
+	pos.limit = min(pos.limit, MaxLimit)
+
+	if pos.sort == "" {
+		pos.sort = q.DefaultSort
+	}
+	pos.key, pos.desc = strings.CutPrefix(pos.sort, "-")
+	column, ok := q.Sorts[pos.key]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort %q, use one of %s", ErrInvalidParams, pos.key, strings.Join(q.sortKeys(), ", "))
+	}
+	pos.column = column
+
+	if params.Cursor != "" {
+		c, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != pos.sort {
+			return nil, fmt.Errorf("%w: cursor was issued for another sort", ErrInvalidParams)
+		}
+		pos.after = &c
+	}
+
+	var invalid apperr.ValidationError
+	for _, name := range sortedNames(params.Filters) {
+		if _, ok := q.Filters[name]; !ok {
+			invalid.Add(name, "unknown filter, use one of "+strings.Join(sortedNames(q.Filters), ", "))
+		}
+	}
+	if err := invalid.Err(); err != nil {
+		return nil, err
+	}
+
+	return pos, nil
+}
+
+// nextCursor returns cursor pointing after item with the sort key value and id.
+func (pos *position) nextCursor(value, id string) string {
+	return encodeCursor(cursor{Sort: pos.sort, Value: value, ID: id})
+}
+
+// Build validates params and returns statements selecting the page.
+func (q *Query) Build(params Params) (*Statements, error) {
+	pos, err := q.parse(params)
+	if err != nil {
+		return nil, err
+	}
+	column := pos.column
+
+	var (
+		conditions []string
+		args       []any
+	)
+
+	// filters are sorted to keep statements and their arguments stable
+	for _, name := range sortedNames(params.Filters) {
+		args = append(args, params.Filters[name])
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", q.Filters[name].Expr, len(args)))
+	}
+
+	st := &Statements{
+		Count:     "select count(*) from " + q.From + where(conditions),
+		CountArgs: append([]any{}, args...),
+		Limit:     pos.limit,
+		Sort:      pos.sort,
+		Key:       pos.key,
+	}
+
+	op, direction := ">", ""
+	if pos.desc {
+		op, direction = "<", " desc"
+	}
+
+	if c := pos.after; c != nil {
+		if column == q.ID {
+			args = append(args, c.ID)
+			conditions = append(conditions, fmt.Sprintf("%s %s $%d::%s", q.ID.Expr, op, len(args), q.ID.Type))
+		} else {
+			args = append(args, c.Value, c.ID)
+			conditions = append(conditions, fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d::%s)",
+				column.Expr, q.ID.Expr, op, len(args)-1, column.Type, len(args), q.ID.Type))
+		}
+	}
+
+	order := column.Expr + direction
+	if column != q.ID {
+		order += ", " + q.ID.Expr + direction
+	}
+
+	args = append(args, pos.limit+1)
+	st.List = fmt.Sprintf("select %s from %s%s order by %s limit $%d", q.Columns, q.From, where(conditions), order, len(args))
+	st.ListArgs = args
+
+	return st, nil
+}
+
+// sortKeys returns allowed sort keys in alphabetical order.
+func (q *Query) sortKeys() []string {
+	return sortedNames(q.Sorts)
+}
+
+// sortedNames returns keys of m in alphabetical order.
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " where " + strings.Join(conditions, " and ")
+}
+
+// Fetch runs statements built from params and returns the page.
+// field returns value of item field by sort key or filter name, "id" must return the ID column.
+func Fetch[T any](
+	ctx context.Context,
+	db database.Querier,
+	q *Query,
+	params Params,
+	scan func(*sql.Rows) (T, error),
+	field func(item T, name string) string,
+) (*Page[T], error) {
+	st, err := q.Build(params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{Items: []T{}}
+	if err := db.QueryRowContext(ctx, st.Count, st.CountArgs...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, st.List, st.ListArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Items) > st.Limit {
+		page.Items = page.Items[:st.Limit]
+		last := page.Items[st.Limit-1]
+		page.NextCursor = encodeCursor(cursor{Sort: st.Sort, Value: field(last, st.Key), ID: field(last, "id")})
+	}
+
+	return page, nil
+}
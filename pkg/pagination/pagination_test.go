@@ -0,0 +1,107 @@
+package pagination
+
+import (
+	"testing"
+
+	"flightticketservice/pkg/apperr"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testQuery = &Query{
+	Columns: "id, name, created_at",
+	From:    "items",
+	ID:      Column{Expr: "id", Type: "int"},
+	Sorts: map[string]Column{
+		"id":         {Expr: "id", Type: "int"},
+		"created_at": {Expr: "created_at", Type: "timestamp"},
+	},
+	DefaultSort: "id",
+	Filters: map[string]Column{
+		"name": {Expr: "name"},
+	},
+}
+
+func TestBuildFirstPage(t *testing.T) {
+	st, err := testQuery.Build(Params{Filters: map[string]string{"name": "x"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "select count(*) from items where name = $1", st.Count)
+	assert.Equal(t, []any{"x"}, st.CountArgs)
+	assert.Equal(t, "select id, name, created_at from items where name = $1 order by id limit $2", st.List)
+	assert.Equal(t, []any{"x", DefaultLimit + 1}, st.ListArgs)
+	assert.Equal(t, DefaultLimit, st.Limit)
+}
+
+func TestBuildWithCursor(t *testing.T) {
+	next := encodeCursor(cursor{Sort: "-created_at", Value: "2026-11-03T08:00:00Z", ID: "42"})
+
+	st, err := testQuery.Build(Params{Limit: 10, Cursor: next, Sort: "-created_at"})
+	assert.NoError(t, err)
+	assert.Equal(t, "select id, name, created_at from items where (created_at, id) < ($1::timestamp, $2::int)"+
+		" order by created_at desc, id desc limit $3", st.List)
+	assert.Equal(t, []any{"2026-11-03T08:00:00Z", "42", 11}, st.ListArgs)
+	assert.Equal(t, "created_at", st.Key)
+
+	st, err = testQuery.Build(Params{Limit: MaxLimit + 1, Cursor: encodeCursor(cursor{Sort: "id", ID: "7"})})
+	assert.NoError(t, err)
+	assert.Equal(t, "select id, name, created_at from items where id > $1::int order by id limit $2", st.List)
+	assert.Equal(t, MaxLimit, st.Limit)
+}
+
+func TestBuildInvalidParams(t *testing.T) {
+	_, err := testQuery.Build(Params{Limit: -1})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+
+	_, err = testQuery.Build(Params{Sort: "name"})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+
+	_, err = testQuery.Build(Params{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+
+	_, err = testQuery.Build(Params{Sort: "created_at", Cursor: encodeCursor(cursor{Sort: "id", ID: "7"})})
+	assert.ErrorIs(t, err, ErrInvalidParams)
+
+	_, err = testQuery.Build(Params{Filters: map[string]string{"name": "x", "nmae": "y"}})
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	assert.Equal(t, []apperr.FieldError{{Field: "nmae", Message: "unknown filter, use one of name"}}, apperr.Fields(err))
+}
+
+type testItem struct {
+	id, name, createdAt string
+}
+
+func testItemField(item testItem, name string) string {
+	switch name {
+	case "name":
+		return item.name
+	case "created_at":
+		return item.createdAt
+	}
+	return item.id
+}
+
+func TestSlice(t *testing.T) {
+	items := []testItem{
+		{"10", "a", "2026-11-03T08:00:00Z"},
+		{"2", "b", "2026-11-01T08:00:00Z"},
+		{"3", "a", "2026-11-03T08:00:00Z"},
+		{"4", "a", "2026-11-02T08:00:00Z"},
+	}
+	params := Params{Limit: 2, Sort: "-created_at", Filters: map[string]string{"name": "a"}}
+
+	page, err := Slice(testQuery, params, items, testItemField)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	assert.Equal(t, []testItem{items[0], items[2]}, page.Items)
+	assert.NotEmpty(t, page.NextCursor)
+
+	params.Cursor = page.NextCursor
+	page, err = Slice(testQuery, params, items, testItemField)
+	assert.NoError(t, err)
+	assert.Equal(t, []testItem{items[3]}, page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	page, err = Slice(testQuery, Params{}, items, testItemField)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "3", "4", "10"}, []string{page.Items[0].id, page.Items[1].id, page.Items[2].id, page.Items[3].id})
+}
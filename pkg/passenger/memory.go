@@ -0,0 +1,178 @@
+package passenger
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"flightticketservice/pkg/database"
+	"flightticketservice/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore keeps passengers in memory, it implements Storage without a database.
+type MemoryStore struct {
+	*memoryData
+	writes database.WriteLock
+}
+
+// memoryData is state of a store shared with its views.
+type memoryData struct {
+	mu         sync.RWMutex
+	passengers map[string]*Passenger
+}
+
+// NewMemoryStore creates empty in-memory passenger store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{memoryData: &memoryData{passengers: map[string]*Passenger{}}}
+}
+
+// WithWriteLock returns view of the store sharing its passengers that locks writes with lock.
+func (ms *MemoryStore) WithWriteLock(lock database.WriteLock) *MemoryStore {
+	return &MemoryStore{memoryData: ms.memoryData, writes: lock}
+}
+
+// CreatePassenger stores passenger and sets its ID
+func (ms *MemoryStore) CreatePassenger(ctx context.Context, pass *Passenger) error {
+	defer ms.writes.Lock()()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.emailTaken(pass.Email, "") {
+		return ErrEmailTaken
+	}
+
+	pass.ID = uuid.NewString()
+
+	stored := *pass
+	ms.passengers[pass.ID] = &stored
+	return nil
+}
+
+// GetPassengers returns page of passengers
+func (ms *MemoryStore) GetPassengers(ctx context.Context, params pagination.Params) (*pagination.Page[*Passenger], error) {
+	ms.mu.RLock()
+	passengers := make([]*Passenger, 0, len(ms.passengers))
+	for _, passenger := range ms.passengers {
+		copied := *passenger
+		passengers = append(passengers, &copied)
+	}
+	ms.mu.RUnlock()
+
+	return pagination.Slice(listPassengers, params, passengers, passengerField)
+}
+
+// GetPassengerByID returns passenger by id
+func (ms *MemoryStore) GetPassengerByID(ctx context.Context, id string) (*Passenger, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	passenger, ok := ms.passengers[id]
+	if !ok {
+		return nil, ErrPassengerNotFound
+	}
+
+	copied := *passenger
+	return &copied, nil
+}
+
+// GetPassengerByEmail returns passenger by email
+func (ms *MemoryStore) GetPassengerByEmail(ctx context.Context, email string) (*Passenger, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	for _, passenger := range ms.passengers {
+		if passenger.Email == email {
+			copied := *passenger
+			return &copied, nil
+		}
+	}
+
+	return nil, ErrPassengerNotFound
+}
+
+// UpdatePassenger updates name and email of passenger by id
+func (ms *MemoryStore) UpdatePassenger(ctx context.Context, id string, newPassenger *Passenger) error {
+	defer ms.writes.Lock()()
+
+	if newPassenger == nil {
+		return errors.New("update request is nil")
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	passenger, ok := ms.passengers[id]
+	if !ok {
+		return ErrPassengerNotFound
+	}
+
+	if ms.emailTaken(newPassenger.Email, id) {
+		return ErrEmailTaken
+	}
+
+	passenger.FirstName = newPassenger.FirstName
+	passenger.LastName = newPassenger.LastName
+	passenger.Email = newPassenger.Email
+	return nil
+}
+
+// SetPassengerRole changes role of passenger by id
+func (ms *MemoryStore) SetPassengerRole(ctx context.Context, id string, role Role) error {
+	defer ms.writes.Lock()()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	passenger, ok := ms.passengers[id]
+	if !ok {
+		return ErrPassengerNotFound
+	}
+
+	passenger.Role = role
+	return nil
+}
+
+// DeletePassenger deletes passenger by id
+func (ms *MemoryStore) DeletePassenger(ctx context.Context, id string) error {
+	defer ms.writes.Lock()()
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.passengers[id]; !ok {
+		return ErrPassengerNotFound
+	}
+
+	delete(ms.passengers, id)
+	return nil
+}
+
+// emailTaken reports if another passenger than exceptID has the email, mu must be held.
+func (ms *MemoryStore) emailTaken(email, exceptID string) bool {
+	for id, passenger := range ms.passengers {
+		if id != exceptID && passenger.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot copies passengers of the store, the returned func puts the copy back.
+func (ms *MemoryStore) Snapshot() (restore func()) {
+	ms.mu.RLock()
+	passengers := make(map[string]*Passenger, len(ms.passengers))
+	for id, passenger := range ms.passengers {
+		copied := *passenger
+		passengers[id] = &copied
+	}
+	ms.mu.RUnlock()
+
+	return func() {
+		ms.mu.Lock()
+		ms.passengers = passengers
+		ms.mu.Unlock()
+	}
+}
@@ -0,0 +1,53 @@
+package passenger
+
+import (
+	"context"
+	"testing"
+
+	"flightticketservice/pkg/pagination"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	john := &Passenger{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	assert.NoError(t, store.CreatePassenger(ctx, john))
+	assert.NoError(t, uuid.Validate(john.ID))
+
+	jane := &Passenger{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+	assert.NoError(t, store.CreatePassenger(ctx, jane))
+	assert.NotEqual(t, john.ID, jane.ID)
+
+	duplicate := &Passenger{FirstName: "Johnny", LastName: "Doe", Email: "john@example.com"}
+	assert.ErrorIs(t, store.CreatePassenger(ctx, duplicate), ErrEmailTaken)
+
+	found, err := store.GetPassengerByEmail(ctx, "jane@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, jane.ID, found.ID)
+
+	assert.ErrorIs(t, store.UpdatePassenger(ctx, jane.ID, &Passenger{Email: "john@example.com"}), ErrEmailTaken)
+	assert.NoError(t, store.UpdatePassenger(ctx, jane.ID, &Passenger{FirstName: "Jane", LastName: "Roe", Email: "jane@example.com"}))
+
+	assert.NoError(t, store.SetPassengerRole(ctx, jane.ID, RoleAgent))
+	assert.ErrorIs(t, store.SetPassengerRole(ctx, "42", RoleAgent), ErrPassengerNotFound)
+	found, err = store.GetPassengerByID(ctx, jane.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, RoleAgent, found.Role)
+	assert.Equal(t, "Roe", found.LastName)
+
+	page, err := store.GetPassengers(ctx, pagination.Params{Limit: 1, Filters: map[string]string{"last_name": "Roe"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+	assert.Equal(t, jane.ID, page.Items[0].ID)
+	assert.Empty(t, page.NextCursor)
+
+	assert.NoError(t, store.DeletePassenger(ctx, john.ID))
+	assert.ErrorIs(t, store.DeletePassenger(ctx, john.ID), ErrPassengerNotFound)
+
+	_, err = store.GetPassengerByID(ctx, john.ID)
+	assert.ErrorIs(t, err, ErrPassengerNotFound)
+}
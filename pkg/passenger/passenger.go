@@ -1,53 +1,53 @@
 package passenger
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"fmt"
+	"time"
 
-	_ "github.com/lib/pq"
+	"flightticketservice/pkg/apperr"
+	"flightticketservice/pkg/database"
+	"flightticketservice/pkg/pagination"
+	"flightticketservice/utils"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer starts spans of store methods, statements they run are traced by the database driver.
+var tracer = otel.Tracer("flightticketservice/pkg/passenger")
+
 // Storage collects methods for postgres
 type Storage interface {
-	CreatePassenger(*Passenger) error
-	GetPassengers() ([]*Passenger, error)
-	GetPassengerByID(passengerID string) (*Passenger, error)
-	GetPassengerByEmail(passengerID string) (*Passenger, error)
-	UpdatePassenger(passengerID string, passenger *Passenger) error
-	DeletePassenger(passengerID string) error
+	CreatePassenger(ctx context.Context, passenger *Passenger) error
+	GetPassengers(ctx context.Context, params pagination.Params) (*pagination.Page[*Passenger], error)
+	GetPassengerByID(ctx context.Context, passengerID string) (*Passenger, error)
+	GetPassengerByEmail(ctx context.Context, passengerID string) (*Passenger, error)
+	UpdatePassenger(ctx context.Context, passengerID string, passenger *Passenger) error
+	SetPassengerRole(ctx context.Context, passengerID string, role Role) error
+	DeletePassenger(ctx context.Context, passengerID string) error
 }
 
+// Passenger store errors.
+var (
+	ErrPassengerNotFound = apperr.New(apperr.ErrNotFound, "passenger_not_found", "passenger not found")
+	ErrEmailTaken        = apperr.New(apperr.ErrConflict, "email_taken", "email is already registered")
+	ErrPassengerBooked   = apperr.New(apperr.ErrConflict, "passenger_booked", "passenger has tickets")
+)
+
 // PostgresStore stores db pointer
 type PostgresStore struct {
-	db *sql.DB
+	db database.Querier
 }
 
-// NewPostgresStore initializes a new PostgresStore with a shared database connection.
-func NewPostgresStore(db *sql.DB) *PostgresStore {
+// NewPostgresStore creates passenger store on db.
+func NewPostgresStore(db database.Querier) *PostgresStore {
 	return &PostgresStore{db: db}
 }
 
-// Init initializes db with data
-func (ps *PostgresStore) Init() error {
-	return ps.CreatePassengerTable()
-}
-
-// CreatePassengerTable creates passenger table in db
-func (ps *PostgresStore) CreatePassengerTable() error {
-	query := `CREATE TABLE IF NOT EXISTS passengers (
-		id SERIAL PRIMARY KEY,
-		first_name VARCHAR(30) NOT NULL,
-		last_name VARCHAR(30) NOT NULL,
-		email VARCHAR(30) UNIQUE NOT NULL,
-		password VARCHAR(100) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`
-
-	_, err := ps.db.Exec(query)
-	return err
-}
-
 // CreatePassenger creates passenger in table
 // @Summary Creates passenger
 // @Description Creates new passenger profile
@@ -58,24 +58,29 @@ func (ps *PostgresStore) CreatePassengerTable() error {
 // @Success 200 "Passenger created"
 // @Failure 400 "Invalid passenger data"
 // @Router /api/v1/passengers/create [post]
-func (ps *PostgresStore) CreatePassenger(pass *Passenger) error {
+func (ps *PostgresStore) CreatePassenger(ctx context.Context, pass *Passenger) (err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.CreatePassenger")
+	defer func() { utils.EndSpan(span, err) }()
+
 	query := `insert into passengers
-	(first_name, last_name, email, password, created_at) 
-	values ($1, $2, $3, $4, $5)`
+	(first_name, last_name, email, password, created_at, role) 
+	values ($1, $2, $3, $4, $5, $6)
+	returning id`
 
-	resp, err := ps.db.Query(
+	err = ps.db.QueryRowContext(
+		ctx,
 		query,
 		pass.FirstName,
 		pass.LastName,
 		pass.Email,
 		pass.Password,
-		pass.CreatedAt)
-
+		pass.CreatedAt,
+		pass.Role).Scan(&pass.ID)
 	if err != nil {
-		return err
+		return emailError(err)
 	}
 
-	fmt.Printf("%+v\n", resp)
+	span.SetAttributes(utils.PassengerIDKey.String(pass.ID))
 	return nil
 }
 
@@ -93,25 +98,60 @@ func (ps *PostgresStore) CreatePassenger(pass *Passenger) error {
 // @Success 200 "Passenger updated"
 // @Failure 404 "Passenger not found"
 // @Router /api/v1/passengers/{id}/update [post]
-func (ps *PostgresStore) UpdatePassenger(id string, newPassenger *Passenger) error {
+func (ps *PostgresStore) UpdatePassenger(ctx context.Context, id string, newPassenger *Passenger) (err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.UpdatePassenger", trace.WithAttributes(utils.PassengerIDKey.String(id)))
+	defer func() { utils.EndSpan(span, err) }()
+
 	if newPassenger == nil {
 		return errors.New("update request is nil")
 	}
+	if !validID(id) {
+		return ErrPassengerNotFound
+	}
 
 	query := "UPDATE passengers SET first_name = $1, last_name = $2, email = $3 WHERE id = $4"
 
-	_, err := ps.db.Query(
+	res, err := ps.db.ExecContext(
+		ctx,
 		query,
 		newPassenger.FirstName,
 		newPassenger.LastName,
 		newPassenger.Email,
 		id)
 
+	if err != nil {
+		return emailError(err)
+	}
+
+	return notFound(ctx, res)
+}
+
+// SetPassengerRole changes role of passenger account
+// @Summary Set passenger role
+// @Description Changes role of a passenger account, available to admins.
+// @Tags passengers
+// @Accept json
+// @Produce json
+// @Param id path string true "Unique identifier of the passenger"
+// @Param role body SetRoleReq true "New role: passenger, agent or admin"
+// @Success 200 "Role changed"
+// @Failure 403 "Permission denied"
+// @Failure 404 "Passenger not found"
+// @Router /api/v1/passengers/{id}/role [post]
+func (ps *PostgresStore) SetPassengerRole(ctx context.Context, id string, role Role) (err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.SetPassengerRole", trace.WithAttributes(utils.PassengerIDKey.String(id)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	if !validID(id) {
+		return ErrPassengerNotFound
+	}
+
+	res, err := ps.db.ExecContext(ctx, "UPDATE passengers SET role = $1 WHERE id = $2", role, id)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return notFound(ctx, res)
 }
 
 // DeletePassenger deletes pasanger from db
@@ -123,9 +163,59 @@ func (ps *PostgresStore) UpdatePassenger(id string, newPassenger *Passenger) err
 // @Param id path string true "Unique identifier of the passenger"
 // @Success 200 "Passenger deleted"
 // @Failure 404 "Passenger not found"
+// @Failure 409 "Passenger has tickets"
 // @Router /api/v1/passengers/{id}/delete [delete]
-func (ps *PostgresStore) DeletePassenger(id string) error {
-	_, err := ps.db.Query("delete from account where id = $1", id)
+func (ps *PostgresStore) DeletePassenger(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.DeletePassenger", trace.WithAttributes(utils.PassengerIDKey.String(id)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	if !validID(id) {
+		return ErrPassengerNotFound
+	}
+
+	res, err := ps.db.ExecContext(ctx, "delete from passengers where id = $1", id)
+	if err != nil {
+		return ticketsError(err)
+	}
+
+	return notFound(ctx, res)
+}
+
+// validID reports if id is a UUID, other ids match no passenger and are not sent to the uuid column.
+func validID(id string) bool {
+	return uuid.Validate(id) == nil
+}
+
+// notFound returns ErrPassengerNotFound if statement affected no rows.
+func notFound(ctx context.Context, res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	utils.SetRows(ctx, rowsAffected)
+
+	if rowsAffected == 0 {
+		return ErrPassengerNotFound
+	}
+
+	return nil
+}
+
+// emailError converts unique violation of passengers email to ErrEmailTaken.
+func emailError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "passengers_email_key" {
+		return ErrEmailTaken
+	}
+	return err
+}
+
+// ticketsError converts violation of the foreign key of tickets to ErrPassengerBooked.
+func ticketsError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "booking_flights_passenger_id_fkey" {
+		return ErrPassengerBooked
+	}
 	return err
 }
 
@@ -139,59 +229,110 @@ func (ps *PostgresStore) DeletePassenger(id string) error {
 // @Success 200 {object} Passenger
 // @Failure 404 "Missing required parameters"
 // @Router /api/v1/passengers/{id} [get]
-func (ps *PostgresStore) GetPassengerByID(id string) (*Passenger, error) {
-	rows, err := ps.db.Query("select * from passengers where id = $1", id)
+func (ps *PostgresStore) GetPassengerByID(ctx context.Context, id string) (_ *Passenger, err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.GetPassengerByID", trace.WithAttributes(utils.PassengerIDKey.String(id)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	if !validID(id) {
+		return nil, ErrPassengerNotFound
+	}
+
+	rows, err := ps.db.QueryContext(ctx, "select "+passengerColumns+" from passengers where id = $1", id)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		return scanPassenger(rows)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return nil, errors.New("passenger not found")
+	return nil, ErrPassengerNotFound
 }
 
 // GetPassengerByEmail returns passenger by email
-func (ps *PostgresStore) GetPassengerByEmail(email string) (*Passenger, error) {
-	rows, err := ps.db.Query("select * from passengers where email = $1", email)
+func (ps *PostgresStore) GetPassengerByEmail(ctx context.Context, email string) (_ *Passenger, err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.GetPassengerByEmail")
+	defer func() { utils.EndSpan(span, err) }()
+
+	rows, err := ps.db.QueryContext(ctx, "select "+passengerColumns+" from passengers where email = $1", email)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		return scanPassenger(rows)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return nil, errors.New("passenger not found")
+	return nil, ErrPassengerNotFound
 }
 
-// GetPassengers return list of passengers
+// GetPassengers return page of passengers
 // @Summary Get list of passengers
-// @Description get passengers
+// @Description get passengers page by page
 // @Tags passengers
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} Passenger
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort key, prefix with - for descending" Enums(id, -id, created_at, -created_at, last_name, -last_name, email, -email)
+// @Param email query string false "Email of the passenger"
+// @Param last_name query string false "Last name of the passenger"
+// @Success 200 {object} pagination.Page[passenger.Passenger]
+// @Failure 400 "Invalid pagination parameters or unknown filter"
 // @Router /api/v1/passengers [get]
-func (ps *PostgresStore) GetPassengers() ([]*Passenger, error) {
-	rows, err := ps.db.Query("select * from passengers")
+func (ps *PostgresStore) GetPassengers(ctx context.Context, params pagination.Params) (page *pagination.Page[*Passenger], err error) {
+	ctx, span := tracer.Start(ctx, "PostgresStore.GetPassengers")
+	defer func() { utils.EndSpan(span, err) }()
+
+	page, err = pagination.Fetch(ctx, ps.db, listPassengers, params, scanPassenger, passengerField)
 	if err != nil {
 		return nil, err
 	}
 
-	passengers := []*Passenger{}
-	for rows.Next() {
-		passenger, err := scanPassenger(rows)
+	utils.SetRows(ctx, int64(len(page.Items)))
+	return page, nil
+}
 
-		if err != nil {
-			return nil, err
-		}
+// passengerColumns are columns of passengers table read by scanPassenger.
+const passengerColumns = "id, first_name, last_name, email, password, created_at, role"
 
-		passengers = append(passengers, passenger)
-	}
+// listPassengers describes sort keys and filters of passengers list, ids are random so passengers are listed by creation.
+var listPassengers = &pagination.Query{
+	Columns: passengerColumns,
+	From:    "passengers",
+	ID:      pagination.Column{Expr: "id", Type: "uuid"},
+	Sorts: map[string]pagination.Column{
+		"id":         {Expr: "id", Type: "uuid"},
+		"created_at": {Expr: "created_at", Type: "timestamp"},
+		"last_name":  {Expr: "last_name", Type: "text"},
+		"email":      {Expr: "email", Type: "text"},
+	},
+	DefaultSort: "created_at",
+	Filters: map[string]pagination.Column{
+		"email":     {Expr: "email"},
+		"last_name": {Expr: "last_name"},
+	},
+}
 
-	return passengers, nil
+// passengerField returns passenger field by sort key or filter name of listPassengers.
+func passengerField(passenger *Passenger, name string) string {
+	switch name {
+	case "created_at":
+		return passenger.CreatedAt.Format(time.RFC3339Nano)
+	case "last_name":
+		return passenger.LastName
+	case "email":
+		return passenger.Email
+	}
+	return passenger.ID
 }
 
 func scanPassenger(rows *sql.Rows) (*Passenger, error) {
@@ -202,7 +343,8 @@ func scanPassenger(rows *sql.Rows) (*Passenger, error) {
 		&passenger.LastName,
 		&passenger.Email,
 		&passenger.Password,
-		&passenger.CreatedAt)
+		&passenger.CreatedAt,
+		&passenger.Role)
 
 	return passenger, err
 }
@@ -3,31 +3,59 @@ package passenger
 import (
 	"time"
 
+	"flightticketservice/pkg/validate"
+
 	"golang.org/x/crypto/bcrypt"
-	"golang.org/x/exp/rand"
 )
 
+// Limits of passenger fields, names and email are VARCHAR(30) columns of passengers table,
+// bcrypt hashes only first 72 bytes of password.
+const (
+	nameMaxLen       = 30
+	emailMaxLen      = 30
+	passwordMinLen   = 8
+	passwordMaxBytes = 72
+)
+
+// Role of a passenger account, it decides which routes the account may call.
+type Role string
+
+// Roles of accounts, self-registered accounts are passengers.
+const (
+	RolePassenger Role = "passenger" // manages own profile, tickets and bookings
+	RoleAgent     Role = "agent"     // books and manages tickets on behalf of passengers
+	RoleAdmin     Role = "admin"     // manages flights and accounts
+)
+
+// Roles lists all roles.
+var Roles = []Role{RolePassenger, RoleAgent, RoleAdmin}
+
 // LoginRequest stores information for login
 type LoginRequest struct {
 	Email    string `json:"email"`
-	Password string `json:"-"`
+	Password string `json:"password"`
 }
 
-// LoginResponse for response after login
+// LoginResponse for response after login, token is access token sent in Authorization header
+// until it expires, then refresh token is exchanged for a new pair at /api/v1/token/refresh.
 type LoginResponse struct {
-	Email string `json:"email"`
-	Token string `json:"token"`
+	Email            string    `json:"email"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
 // Passenger stores information about a user.
+// ID is random UUID assigned by the store, it never changes and is the subject of access tokens.
 type Passenger struct {
-	ID        string    `json:"id"`
+	ID        string    `json:"id" format:"uuid"`
 	FirstName string    `json:"first_name"`
 	LastName  string    `json:"last_name"`
 	Email     string    `json:"email"`
 	Password  string    `json:"-"`
 	CreatedAt time.Time `json:"created_at"`
-	Number    int64     `json:"number"`
+	Role      Role      `json:"role"`
 }
 
 // CreatePassengerReq collects info about passenger for request.
@@ -35,7 +63,30 @@ type CreatePassengerReq struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Email     string `json:"email"`
-	Password  string `json:"-"`
+	Password  string `json:"password"`
+}
+
+// Validate checks fields of the request.
+func (r *CreatePassengerReq) Validate() error {
+	return validate.All(
+		validate.Field("first_name", r.FirstName, validate.Required[string](), validate.MaxLen(nameMaxLen)),
+		validate.Field("last_name", r.LastName, validate.Required[string](), validate.MaxLen(nameMaxLen)),
+		validate.Field("email", r.Email, validate.Required[string](), validate.MaxLen(emailMaxLen), validate.Email()),
+		validate.Field("password", r.Password,
+			validate.Required[string](), validate.MinLen(passwordMinLen), validate.MaxBytes(passwordMaxBytes)),
+	)
+}
+
+// SetRoleReq collects new role of passenger account.
+type SetRoleReq struct {
+	Role Role `json:"role"`
+}
+
+// Validate checks fields of the request.
+func (r *SetRoleReq) Validate() error {
+	return validate.All(
+		validate.Field("role", r.Role, validate.Required[Role](), validate.OneOf(Roles...)),
+	)
 }
 
 // ValidPassword check if enctypted password is valid
@@ -56,6 +107,6 @@ func NewPassenger(firstName, lastName, email, password string) (*Passenger, erro
 		Email:     email,
 		Password:  string(encpw),
 		CreatedAt: time.Now().UTC(),
-		Number:    int64(rand.Intn(1000000)),
+		Role:      RolePassenger,
 	}, nil
 }
@@ -29,6 +29,6 @@ func TestNewPassenger(t *testing.T) {
 
 	assert.WithinDuration(t, time.Now().UTC(), passenger.CreatedAt, time.Second, "Expected CreatedAt to be recent")
 
-	assert.GreaterOrEqual(t, passenger.Number, int64(0), "Expected Number to be non-negative")
-	assert.Less(t, passenger.Number, int64(1000000), "Expected Number to be less than 1000000")
+	assert.Empty(t, passenger.ID, "Expected ID to be assigned by the store")
+	assert.Equal(t, RolePassenger, passenger.Role)
 }
\ No newline at end of file
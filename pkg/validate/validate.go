@@ -0,0 +1,199 @@
+// Package validate checks fields of requests against declarative rules
+// and reports every invalid field in apperr.ValidationError.
+//
+// Request types list their rules in Validate method:
+//
+//	func (r *CreateFlightReq) Validate() error {
+//		return validate.All(
+//			validate.Field("airline", r.Airline, validate.Required[string](), validate.MaxLen(30)),
+//			validate.Field("price", r.Price, validate.Positive[float64]()),
+//		)
+//	}
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"flightticketservice/pkg/apperr"
+
+	"github.com/google/uuid"
+)
+
+// Rule checks a value, it returns message describing why the value is invalid, or empty string if it is valid.
+type Rule[T any] func(value T) string
+
+// Check adds invalid fields it finds to the validation error.
+type Check func(invalid *apperr.ValidationError)
+
+// Field checks value of the named field with rules, only the first broken rule is reported.
+func Field[T any](name string, value T, rules ...Rule[T]) Check {
+	return func(invalid *apperr.ValidationError) {
+		for _, rule := range rules {
+			if message := rule(value); message != "" {
+				invalid.Add(name, message)
+				return
+			}
+		}
+	}
+}
+
+// Each checks every element of values with rules, fields are named name[i].
+func Each[T any](name string, values []T, rules ...Rule[T]) Check {
+	return func(invalid *apperr.ValidationError) {
+		for i, value := range values {
+			Field(fmt.Sprintf("%s[%d]", name, i), value, rules...)(invalid)
+		}
+	}
+}
+
+// When runs checks only if cond holds, e.g. to compare fields after both are known to be set.
+func When(cond bool, checks ...Check) Check {
+	return func(invalid *apperr.ValidationError) {
+		if cond {
+			for _, check := range checks {
+				check(invalid)
+			}
+		}
+	}
+}
+
+// All runs checks and returns validation error listing invalid fields, nil if there are none.
+func All(checks ...Check) error {
+	var invalid apperr.ValidationError
+	for _, check := range checks {
+		check(&invalid)
+	}
+	return invalid.Err()
+}
+
+// Required rejects zero value, like empty string or zero time.
+func Required[T comparable]() Rule[T] {
+	return func(value T) string {
+		var zero T
+		if value == zero {
+			return "is required"
+		}
+		return ""
+	}
+}
+
+// NotEmpty rejects empty slices.
+func NotEmpty[T any]() Rule[[]T] {
+	return func(values []T) string {
+		if len(values) == 0 {
+			return "must not be empty"
+		}
+		return ""
+	}
+}
+
+// OneOf rejects values other than the listed ones.
+func OneOf[T comparable](values ...T) Rule[T] {
+	return func(value T) string {
+		for _, allowed := range values {
+			if value == allowed {
+				return ""
+			}
+		}
+		names := make([]string, 0, len(values))
+		for _, allowed := range values {
+			names = append(names, fmt.Sprint(allowed))
+		}
+		return "must be one of " + strings.Join(names, ", ")
+	}
+}
+
+// MaxLen rejects strings longer than n characters, like VARCHAR(n) columns do.
+func MaxLen(n int) Rule[string] {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) > n {
+			return fmt.Sprintf("must be at most %d characters", n)
+		}
+		return ""
+	}
+}
+
+// MinLen rejects strings shorter than n characters.
+func MinLen(n int) Rule[string] {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) < n {
+			return fmt.Sprintf("must be at least %d characters", n)
+		}
+		return ""
+	}
+}
+
+// MaxBytes rejects strings longer than n bytes.
+func MaxBytes(n int) Rule[string] {
+	return func(value string) string {
+		if len(value) > n {
+			return fmt.Sprintf("must be at most %d bytes", n)
+		}
+		return ""
+	}
+}
+
+// Email rejects strings that are not a bare email address, like name@example.com.
+func Email() Rule[string] {
+	return func(value string) string {
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value {
+			return "must be a valid email address"
+		}
+		return ""
+	}
+}
+
+// UUID rejects strings that are not a UUID in canonical form, like 123e4567-e89b-12d3-a456-426614174000.
+func UUID() Rule[string] {
+	return func(value string) string {
+		if len(value) != 36 || uuid.Validate(value) != nil {
+			return "must be a UUID"
+		}
+		return ""
+	}
+}
+
+// NotEqualFold rejects strings equal to the other field ignoring case.
+func NotEqualFold(otherName, other string) Rule[string] {
+	return func(value string) string {
+		if strings.EqualFold(value, other) {
+			return "must differ from " + otherName
+		}
+		return ""
+	}
+}
+
+// Positive rejects numbers not greater than zero.
+func Positive[T int | int64 | float64]() Rule[T] {
+	return func(value T) string {
+		if value <= 0 {
+			return "must be greater than 0"
+		}
+		return ""
+	}
+}
+
+// Min rejects numbers less than n.
+func Min[T int | int64 | float64](n T) Rule[T] {
+	return func(value T) string {
+		if value < n {
+			return fmt.Sprintf("must be at least %v", n)
+		}
+		return ""
+	}
+}
+
+// After rejects times not after the other field.
+func After(otherName string, other time.Time) Rule[time.Time] {
+	return func(value time.Time) string {
+		if !value.After(other) {
+			return "must be after " + otherName
+		}
+		return ""
+	}
+}
@@ -0,0 +1,54 @@
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"flightticketservice/pkg/apperr"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAll(t *testing.T) {
+	departure := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	err := All(
+		Field("airline", "", Required[string](), MaxLen(3)),
+		Field("origin", "Москва", MaxLen(6)),
+		Field("destination", "москва", NotEqualFold("origin", "Москва")),
+		Field("arrival", departure, After("departure", departure)),
+		Field("price", -1.5, Positive[float64]()),
+		Field("capacity", 0, Min(0)),
+		Field("role", "pilot", OneOf("passenger", "admin")),
+		Each("seats", []string{"1A", "", "2B"}, Required[string]()),
+		When(false, Field("email", "", Required[string]())),
+	)
+
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	assert.Equal(t, []apperr.FieldError{
+		{Field: "airline", Message: "is required"},
+		{Field: "destination", Message: "must differ from origin"},
+		{Field: "arrival", Message: "must be after departure"},
+		{Field: "price", Message: "must be greater than 0"},
+		{Field: "role", Message: "must be one of passenger, admin"},
+		{Field: "seats[1]", Message: "is required"},
+	}, apperr.Fields(err))
+
+	assert.NoError(t, All(Field("airline", "S7", Required[string](), MaxLen(30))))
+}
+
+func TestEmail(t *testing.T) {
+	rule := Email()
+	assert.Empty(t, rule("ivan@example.com"))
+	assert.NotEmpty(t, rule("ivan"))
+	assert.NotEmpty(t, rule("Ivan <ivan@example.com>"))
+	assert.NotEmpty(t, rule(""))
+}
+
+func TestUUID(t *testing.T) {
+	rule := UUID()
+	assert.Empty(t, rule("123e4567-e89b-12d3-a456-426614174000"))
+	assert.NotEmpty(t, rule("42"))
+	assert.NotEmpty(t, rule("{123e4567-e89b-12d3-a456-426614174000}"))
+	assert.NotEmpty(t, rule("123e4567e89b12d3a456426614174000"))
+}
@@ -1,19 +1,91 @@
 package utils
 
 import (
-	"log"
-	"os"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
 )
 
-// logger
-var (
-	InfoLog    *log.Logger
-	WarningLog *log.Logger
-	ErrorLog   *log.Logger
+// Log output formats.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
 )
 
-func init() {
-	InfoLog = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	WarningLog = log.New(os.Stdout, "WARNING: ", log.Ldate|log.Ltime|log.Lshortfile)
-	ErrorLog = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
+// NewLogger creates logger writing records of level and above to w in text or json format.
+// Level is one of debug, info, warn and error, empty format and level mean text and info.
+// Records logged with a context also get attributes added to it by WithLogAttrs.
+func NewLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("log level %q: %w", level, err)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch format {
+	case "", LogFormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case LogFormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("log format %q: use %s or %s", format, LogFormatText, LogFormatJSON)
+	}
+
+	return slog.New(&contextHandler{handler}), nil
+}
+
+// DiscardLogger returns logger that drops all records, for tests and optional dependencies.
+func DiscardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+type logAttrsKey struct{}
+
+// WithLogAttrs returns ctx whose log records get args as attributes,
+// args are key-value pairs or slog.Attr like in slog.Logger.Info.
+func WithLogAttrs(ctx context.Context, args ...any) context.Context {
+	attrs := slog.Group("", args...).Value.Group()
+	if parent, ok := ctx.Value(logAttrsKey{}).([]slog.Attr); ok {
+		attrs = append(slices.Clip(parent), attrs...)
+	}
+	return context.WithValue(ctx, logAttrsKey{}, attrs)
+}
+
+// contextHandler adds attributes of record context to the record,
+// attributes logged with the record win over context ones with the same key.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs, ok := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	if !ok {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	logged := make(map[string]bool, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		logged[attr.Key] = true
+		return true
+	})
+	for _, attr := range attrs {
+		if !logged[attr.Key] {
+			r.AddAttrs(attr)
+			logged[attr.Key] = true
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
 }
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, LogFormatJSON, "warn")
+	assert.NoError(t, err)
+
+	ctx := WithLogAttrs(context.Background(), "request_id", "req-1")
+	ctx = WithLogAttrs(ctx, "ticket_id", "42")
+	logger.InfoContext(ctx, "ticket booked")
+	logger.WarnContext(ctx, "seat taken", "seat_number", "1A", "ticket_id", "43")
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "seat taken", record["msg"])
+	assert.Equal(t, "1A", record["seat_number"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "43", record["ticket_id"])
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte(`"ticket_id"`)))
+
+	_, err = NewLogger(&buf, "xml", "")
+	assert.Error(t, err)
+	_, err = NewLogger(&buf, LogFormatText, "verbose")
+	assert.Error(t, err)
+}
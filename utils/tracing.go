@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName names the service in exported spans unless OTEL_SERVICE_NAME is set.
+const ServiceName = "flightticketservice"
+
+// Span attributes, named like log attributes so spans and records of a request can be matched.
+const (
+	FlightIDKey    = attribute.Key("flight_id")
+	PassengerIDKey = attribute.Key("passenger_id")
+	TicketIDKey    = attribute.Key("ticket_id")
+	LocatorKey     = attribute.Key("locator")
+	RowsKey        = attribute.Key("rows")
+)
+
+// Span exporters.
+const (
+	TraceExporterNone   = "none"
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+)
+
+// NewTracerProvider creates tracer provider exporting spans with exporter:
+// otlp sends them over OTLP/HTTP configured by standard OTEL_EXPORTER_OTLP_* variables,
+// stdout and file write them as JSON to stdout or appended to the file at path.
+// Empty exporter or none returns nil provider, spans are not recorded then.
+// Shutdown of the provider flushes pending spans, the returned closer must be called after it.
+func NewTracerProvider(ctx context.Context, exporter, path string) (*sdktrace.TracerProvider, func() error, error) {
+	nop := func() error { return nil }
+
+	var (
+		spanExporter sdktrace.SpanExporter
+		closer       = nop
+		err          error
+	)
+	switch exporter {
+	case "", TraceExporterNone:
+		return nil, nop, nil
+	case TraceExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	case TraceExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case TraceExporterFile:
+		if path == "" {
+			return nil, nil, errors.New("trace exporter file: path is required")
+		}
+		var f *os.File
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open trace file: %w", err)
+		}
+		closer = f.Close
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, nil, fmt.Errorf("trace exporter %q: use %s, %s, %s or %s",
+			exporter, TraceExporterNone, TraceExporterOTLP, TraceExporterStdout, TraceExporterFile)
+	}
+	if err != nil {
+		closer()
+		return nil, nil, fmt.Errorf("create %s trace exporter: %w", exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		closer()
+		return nil, nil, fmt.Errorf("create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	return provider, closer, nil
+}
+
+// EndSpan records err on span, if any, and ends it.
+// Use it deferred with named error result: defer func() { utils.EndSpan(span, err) }().
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SetRows sets number of rows returned or affected by statements on the span of ctx.
+func SetRows(ctx context.Context, rows int64) {
+	trace.SpanFromContext(ctx).SetAttributes(RowsKey.Int64(rows))
+}
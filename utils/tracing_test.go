@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	provider, closer, err := NewTracerProvider(context.Background(), "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, provider)
+	assert.NoError(t, closer())
+
+	_, _, err = NewTracerProvider(context.Background(), "zipkin", "")
+	assert.Error(t, err)
+	_, _, err = NewTracerProvider(context.Background(), TraceExporterFile, "")
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	provider, closer, err = NewTracerProvider(context.Background(), TraceExporterFile, path)
+	assert.NoError(t, err)
+
+	_, span := provider.Tracer("test").Start(context.Background(), "BookingStore.CancelTicket")
+	span.SetAttributes(TicketIDKey.String("42"))
+	EndSpan(span, errors.New("ticket not found"))
+
+	assert.NoError(t, provider.Shutdown(context.Background()))
+	assert.NoError(t, closer())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"BookingStore.CancelTicket"`)
+	assert.Contains(t, string(data), `"Description":"ticket not found"`)
+	assert.Contains(t, string(data), `"Value":"flightticketservice"`)
+}